@@ -0,0 +1,121 @@
+// Command fmt (monkeyfmt) formats Monkey source files.
+//
+// With no paths it formats standard input. Directories are walked for
+// .monkey files.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/format"
+)
+
+var (
+	write = flag.Bool("w", false, "write the result back to the file instead of stdout")
+	list  = flag.Bool("l", false, "list files whose formatting differs")
+	diff  = flag.Bool("d", false, "print diffs instead of the formatted source")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: monkeyfmt [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	exitCode := 0
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "monkeyfmt: can't use -w with standard input")
+			os.Exit(2)
+		}
+		if err := processFile("<standard input>", os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+		}
+		os.Exit(exitCode)
+	}
+	for _, path := range flag.Args() {
+		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (!isArg(path) && !strings.HasSuffix(path, ".monkey")) {
+				return nil
+			}
+			if err := processFile(path, nil, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exitCode = 2
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 2
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// isArg reports whether path was named on the command line, in which case
+// it is formatted whatever its extension.
+func isArg(path string) bool {
+	for _, a := range flag.Args() {
+		if a == path {
+			return true
+		}
+	}
+	return false
+}
+
+// processFile formats the file at path, read from in if it isn't nil, and
+// reports the result according to the flags.
+func processFile(path string, in io.Reader, out io.Writer) error {
+	var src []byte
+	var err error
+	if in != nil {
+		src, err = io.ReadAll(in)
+	} else {
+		src, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	res, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	if bytes.Equal(src, res) {
+		if !*list && !*write && !*diff {
+			_, err = out.Write(res)
+		}
+		return err
+	}
+	if *list {
+		fmt.Fprintln(out, path)
+	}
+	if *write {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if *diff {
+		out.Write(unifiedDiff(path, src, res))
+	}
+	if !*list && !*write && !*diff {
+		_, err = out.Write(res)
+	}
+	return err
+}
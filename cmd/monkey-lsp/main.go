@@ -0,0 +1,22 @@
+// Command monkey-lsp is a language server for Monkey. Editors start it and
+// talk to it over standard input and output, see package lsp for what it
+// can do.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/myselfBZ/interpreter/internal/lsp"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: monkey-lsp\n\nmonkey-lsp speaks the Language Server Protocol on standard input and output.")
+		os.Exit(2)
+	}
+	if err := lsp.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "monkey-lsp:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/checker"
+	"github.com/myselfBZ/interpreter/internal/lint"
+)
+
+// checkCmd parses a script, expands its macros, resolves its names and
+// checks its types without running it, and reports any errors found on
+// the way. If there are none it lints the script, like lintCmd.
+func checkCmd(args []string) int {
+	fs := newFlagSet("check")
+	configPath := fs.String("config", "", "read the lint rules to apply from `file`, "+lint.ConfigFile+" by default")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	config, err := lintConfig(*configPath)
+	if err != nil {
+		return fail(err)
+	}
+	// the comments are kept for lint:ignore
+	program, err := parseFile(files[0], true)
+	if err != nil {
+		return fail(err)
+	}
+	// the linter looks at the code as written, before macros expand
+	findings := lint.Lint(files[0], program, config)
+	if program, err = expandMacros(files[0], program); err != nil {
+		return fail(err)
+	}
+	if err := resolve(files[0], program, nil); err != nil {
+		return fail(err)
+	}
+	if errs := checker.Check(program); len(errs) != 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Error()
+		}
+		// type errors are found before the script runs, like syntax
+		// errors, and reported the same way
+		return fail(&syntaxError{path: files[0], errors: msgs})
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) != 0 {
+		return exitRuntimeError
+	}
+	return exitOK
+}
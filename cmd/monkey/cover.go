@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/myselfBZ/interpreter/internal/cover"
+)
+
+// coverCmd reads a profile written by `run --coverprofile` and prints how
+// much of each script ran, or with --html a page showing which code did.
+func coverCmd(args []string) int {
+	fs := newFlagSet("cover")
+	html := fs.Bool("html", false, "write an HTML page with the source colored by coverage")
+	output := fs.String("o", "", "write the HTML page to `file` instead of standard output")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	f, err := openSource(files[0])
+	if err != nil {
+		return fail(err)
+	}
+	blocks, err := cover.ReadProfile(f)
+	f.Close()
+	if err != nil {
+		return fail(fmt.Errorf("%s: %w", files[0], err))
+	}
+
+	if !*html {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "file\tstatements\tbranches")
+		for _, s := range cover.Summarize(blocks) {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Path, cover.Percent(s.Covered, s.Statements), cover.Percent(s.Taken, s.Branches))
+		}
+		if err := tw.Flush(); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	err = cover.WriteHTML(out, blocks, os.ReadFile)
+	if *output != "" {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
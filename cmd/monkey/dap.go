@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/myselfBZ/interpreter/internal/dap"
+)
+
+// dapCmd serves the Debug Adapter Protocol for one debugging session, on
+// standard input and output or on a TCP connection.
+func dapCmd(args []string) int {
+	fs := newFlagSet("dap")
+	listen := fs.String("listen", "", "wait for the client on this TCP address instead of using standard input and output")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(rest) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *listen == "" {
+		if err := dap.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "monkey dap: listening on %s\n", ln.Addr())
+	conn, err := ln.Accept()
+	ln.Close()
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	if err := dap.NewServer().Serve(conn, conn); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
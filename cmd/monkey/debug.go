@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/debugger"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+const debugHelp = `commands:
+    break <line>, b      stop whenever line is reached
+    clear <line>         remove the breakpoint on line
+    breakpoints          list the breakpoints
+    continue, c          run to the next breakpoint
+    next, n              run to the next line, stepping over calls
+    step, s              run to the next line, stepping into calls
+    out, o               run until the current function returns
+    print <expr>, p      evaluate expr in the selected frame
+    env                  list the bindings of the selected frame, scope by scope
+    backtrace, bt        list the calls in progress
+    frame <n>, f         select frame n of the backtrace
+    list, l              show the source around the current line
+    quit, q              end the program
+`
+
+// debugSession is `monkey debug` talking to the user between stops.
+type debugSession struct {
+	path  string
+	lines []string
+	in    *bufio.Scanner
+	out   io.Writer
+	// frame is the frame print and env look at, 0 being the innermost
+	frame int
+}
+
+func debugCmd(args []string) int {
+	fs := newFlagSet("debug")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	path := files[0]
+	if path == "-" {
+		// the commands come from there
+		fmt.Fprintln(os.Stderr, "monkey debug: the script has to be a file, not standard input")
+		return exitUsage
+	}
+	src, err := openSource(path)
+	if err != nil {
+		return fail(err)
+	}
+	text, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return fail(err)
+	}
+	// the comments are kept for help() to show
+	l := lexer.New(string(text))
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fail(&syntaxError{path: path, errors: p.Errors()})
+	}
+	program, err = expandMacros(path, program)
+	if err != nil {
+		return fail(err)
+	}
+	env := object.NewEnviroment()
+	if err := resolve(path, program, env); err != nil {
+		return fail(err)
+	}
+
+	s := &debugSession{
+		path:  path,
+		lines: strings.Split(string(text), "\n"),
+		in:    bufio.NewScanner(os.Stdin),
+		out:   os.Stdout,
+	}
+	d := debugger.New(s.stopped)
+	d.StopOnEntry(true)
+	fmt.Fprintf(s.out, "debugging %s, type help for the commands\n", path)
+	result, err := d.Run(program, env)
+	if err == debugger.ErrAborted {
+		return exitOK
+	}
+	if e, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, runtimeError(path, e))
+		return exitRuntimeError
+	}
+	if result != nil {
+		fmt.Fprintln(s.out, result.Inspect())
+	}
+	return exitOK
+}
+
+// stopped shows where the program stopped and reads commands until one
+// of them resumes it.
+func (s *debugSession) stopped(d *debugger.Debugger, stop debugger.Stop) debugger.Resume {
+	s.frame = 0
+	fmt.Fprintf(s.out, "stopped at %s:%d:%d (%s)\n", s.path, stop.Line, stop.Column, stop.Reason)
+	s.show(stop.Line, 0)
+	for {
+		fmt.Fprint(s.out, "(debug) ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			return debugger.Abort
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(s.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "":
+		case "continue", "c":
+			return debugger.Continue
+		case "next", "n":
+			return debugger.StepOver
+		case "step", "s":
+			return debugger.StepIn
+		case "out", "o":
+			return debugger.StepOut
+		case "quit", "q":
+			return debugger.Abort
+		case "break", "b", "clear":
+			line, err := strconv.Atoi(arg)
+			if err != nil || line < 1 {
+				fmt.Fprintf(s.out, "usage: %s <line>\n", cmd)
+				continue
+			}
+			if cmd == "clear" {
+				d.ClearBreakpoint(line)
+			} else {
+				d.SetBreakpoint(line)
+			}
+		case "breakpoints":
+			for _, line := range d.Breakpoints() {
+				fmt.Fprintf(s.out, "%s:%d\n", s.path, line)
+			}
+		case "print", "p":
+			obj, err := d.Eval(arg, s.frame)
+			switch {
+			case err != nil:
+				fmt.Fprintln(s.out, "error:", err)
+			case obj != nil:
+				fmt.Fprintln(s.out, obj.Inspect())
+			}
+		case "env":
+			s.env(d.Frames()[s.frame].Env)
+		case "backtrace", "bt":
+			for i, f := range d.Frames() {
+				mark := " "
+				if i == s.frame {
+					mark = "*"
+				}
+				fmt.Fprintf(s.out, "%s #%d %s at %s:%d:%d\n", mark, i, f.Name, s.path, f.Line, f.Column)
+			}
+		case "frame", "f":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 || n >= len(d.Frames()) {
+				fmt.Fprintf(s.out, "usage: frame <n>, with n from 0 to %d\n", len(d.Frames())-1)
+				continue
+			}
+			s.frame = n
+			f := d.Frames()[n]
+			s.show(f.Line, 0)
+		case "list", "l":
+			s.show(d.Frames()[s.frame].Line, 3)
+		case "help", "h":
+			fmt.Fprint(s.out, debugHelp)
+		default:
+			fmt.Fprintf(s.out, "unknown command %q, type help for the commands\n", cmd)
+		}
+	}
+}
+
+// show prints line of the script with context lines around it, marking
+// line with an arrow.
+func (s *debugSession) show(line, context int) {
+	for n := max(line-context, 1); n <= line+context && n <= len(s.lines); n++ {
+		mark := "  "
+		if n == line {
+			mark = "=>"
+		}
+		fmt.Fprintf(s.out, "%s %4d  %s\n", mark, n, s.lines[n-1])
+	}
+}
+
+// env prints the bindings of env and the enviroments it is enclosed in,
+// the innermost first.
+func (s *debugSession) env(env *object.Enviroment) {
+	for depth := 0; env != nil; depth++ {
+		if env.Outer() == nil {
+			fmt.Fprintln(s.out, "global:")
+		} else {
+			fmt.Fprintf(s.out, "scope %d:\n", depth)
+		}
+		for _, name := range env.LocalNames() {
+			obj, _ := env.Get(name)
+			fmt.Fprintf(s.out, "    %s = %s\n", name, debugger.Summary(obj))
+		}
+		env = env.Outer()
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/myselfBZ/interpreter/internal/doc"
+)
+
+// docCmd writes the API documentation of the scripts in a directory, from
+// the comments above their functions, as Markdown or HTML pages.
+func docCmd(args []string) int {
+	fs := newFlagSet("doc")
+	html := fs.Bool("html", false, "write HTML pages instead of Markdown")
+	output := fs.String("o", "doc", "write the pages to `dir`")
+	dirs, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(dirs) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	// the module names are paths from the directory, so a file would get
+	// an empty one
+	if info, err := os.Stat(dirs[0]); err != nil {
+		return fail(err)
+	} else if !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "monkey doc: %s is not a directory\n", dirs[0])
+		return exitUsage
+	}
+	paths, err := doc.Find(dirs[0])
+	if err != nil {
+		return fail(err)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "monkey doc: no scripts in %s\n", dirs[0])
+		return exitUsage
+	}
+	var modules []*doc.Module
+	for _, path := range paths {
+		program, err := parseFile(path, true)
+		if err != nil {
+			return fail(err)
+		}
+		modules = append(modules, doc.New(doc.ModuleName(dirs[0], path), program))
+	}
+
+	format := doc.Markdown
+	if *html {
+		format = doc.HTML
+	}
+	pages, err := doc.Render(modules, format)
+	if err != nil {
+		return fail(err)
+	}
+	for _, page := range pages {
+		path := filepath.Join(*output, filepath.FromSlash(page.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fail(err)
+		}
+		if err := os.WriteFile(path, page.Data, 0o644); err != nil {
+			return fail(err)
+		}
+	}
+	return exitOK
+}
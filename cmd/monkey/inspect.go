@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/tidwall/pretty"
+)
+
+func tokensCmd(args []string) int {
+	fs := newFlagSet("tokens")
+	asJSON := fs.Bool("json", false, "print one JSON object per token")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	l, src, err := newLexer(files[0])
+	if err != nil {
+		return fail(err)
+	}
+	defer src.Close()
+	l.EmitComments(true)
+	tokens := l.Tokens()
+	if l.Err() != nil {
+		return fail(l.Err())
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, t := range tokens {
+			if err := enc.Encode(t); err != nil {
+				return fail(err)
+			}
+		}
+		return exitOK
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "POSITION\tTYPE\tLITERAL")
+	for _, t := range tokens {
+		fmt.Fprintf(w, "%d:%d\t%s\t%q\n", t.Line, t.Column, t.Type, t.Literal)
+	}
+	w.Flush()
+	return exitOK
+}
+
+func astCmd(args []string) int {
+	fs := newFlagSet("ast")
+	asJSON := fs.Bool("json", false, "print the tree as JSON, in the format run --from-json reads")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	program, err := parseFile(files[0], *asJSON)
+	if err != nil {
+		return fail(err)
+	}
+	if !*asJSON {
+		fmt.Print(program.String())
+		return exitOK
+	}
+	programBytes, err := ast.MarshalJSON(program)
+	if err != nil {
+		return fail(fmt.Errorf("error marshaling the program: %w", err))
+	}
+	os.Stdout.Write(pretty.Pretty(programBytes))
+	return exitOK
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/myselfBZ/interpreter/internal/lint"
+	"github.com/tidwall/pretty"
+)
+
+// lintCmd reports the code in scripts that is legal but likely a mistake.
+// The rules to apply come from --config, or from the .monkeylint.json of
+// the current directory if there is one.
+func lintCmd(args []string) int {
+	flags := newFlagSet("lint")
+	asJSON := flags.Bool("json", false, "print the findings as a JSON array")
+	configPath := flags.String("config", "", "read the rules to apply from `file`, "+lint.ConfigFile+" by default")
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) == 0 {
+		flags.Usage()
+		fmt.Fprintln(os.Stderr, "\nrules:")
+		for _, r := range lint.Rules {
+			fmt.Fprintf(os.Stderr, "    %-13s %s\n", r.Name, r.Doc)
+		}
+		return exitUsage
+	}
+	config, err := lintConfig(*configPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	findings := []*lint.Finding{}
+	for _, path := range files {
+		program, err := parseFile(path, true)
+		if err != nil {
+			return fail(err)
+		}
+		findings = append(findings, lint.Lint(path, program, config)...)
+	}
+	if *asJSON {
+		data, err := json.Marshal(findings)
+		if err != nil {
+			return fail(err)
+		}
+		os.Stdout.Write(pretty.Pretty(data))
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	if len(findings) != 0 {
+		return exitRuntimeError
+	}
+	return exitOK
+}
+
+// lintConfig reads the lint rules from the file at path, or from the
+// .monkeylint.json of the current directory if path is empty. Without
+// either every rule applies.
+func lintConfig(path string) (*lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+	config, err := lint.LoadConfig(lint.ConfigFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/resolver"
+)
+
+// syntaxError holds the complaints about a script found before it runs:
+// the parser's, and those of the passes after it.
+type syntaxError struct {
+	path   string
+	errors []string
+}
+
+func (e *syntaxError) Error() string {
+	var out strings.Builder
+	for i, msg := range e.errors {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		// messages that start with a position read path:line:column: msg
+		var line, column int
+		if n, _ := fmt.Sscanf(msg, "%d:%d:", &line, &column); n == 2 {
+			out.WriteString(e.path + ":" + strings.TrimSpace(msg))
+		} else {
+			out.WriteString(e.path + ": " + strings.TrimSpace(msg))
+		}
+	}
+	return out.String()
+}
+
+// fail prints err and returns the exit status that goes with it.
+func fail(err error) int {
+	if err == errUsage {
+		return exitUsage
+	}
+	fmt.Fprintln(os.Stderr, err)
+	var syntax *syntaxError
+	if errors.As(err, &syntax) {
+		return exitUsage
+	}
+	return exitRuntimeError
+}
+
+// openSource opens the script at path, or standard input for "-".
+func openSource(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// newLexer returns a lexer over the script at path, which the caller has to
+// close when done.
+func newLexer(path string) (*lexer.Lexer, io.Closer, error) {
+	src, err := openSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lexer.NewReader(src), src, nil
+}
+
+// parseFile reads and parses the script at path. If comments is set the
+// program keeps the script's comments.
+func parseFile(path string, comments bool) (*ast.Program, error) {
+	l, src, err := newLexer(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	l.EmitComments(comments)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if l.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", path, l.Err())
+	}
+	if len(p.Errors()) != 0 {
+		return nil, &syntaxError{path: path, errors: p.Errors()}
+	}
+	return program, nil
+}
+
+// readJSONFile reads a syntax tree written in the ast package's JSON format,
+// by `monkey ast --json` or by another tool.
+func readJSONFile(path string) (*ast.Program, error) {
+	src, err := openSource(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	node, err := ast.UnmarshalJSON(data)
+	if err != nil {
+		return nil, &syntaxError{path: path, errors: []string{err.Error()}}
+	}
+	program, ok := node.(*ast.Program)
+	if !ok {
+		return nil, &syntaxError{path: path, errors: []string{fmt.Sprintf("expected a Program, got %T", node)}}
+	}
+	return program, nil
+}
+
+// expandMacros runs the macro expansion pass over program, which has to
+// happen before it is evaluated.
+func expandMacros(path string, program *ast.Program) (*ast.Program, error) {
+	macroEnv := object.NewEnviroment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, err := evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		return nil, &syntaxError{path: path, errors: []string{err.Error()}}
+	}
+	return expanded, nil
+}
+
+// resolve runs the resolver over program, which is going to run in env,
+// and returns the identifiers nothing binds as an error.
+func resolve(path string, program *ast.Program, env *object.Enviroment) error {
+	errs := resolver.Resolve(program, env)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return &syntaxError{path: path, errors: msgs}
+}
@@ -0,0 +1,139 @@
+// Command monkey runs and inspects Monkey programs.
+//
+//	monkey run <file> [args]     run a script
+//	monkey repl                  start the interactive prompt
+//	monkey tokens <file> [--json] print the tokens of a script
+//	monkey ast <file> [--json]   print the syntax tree of a script
+//	monkey check [--config file] <file> report syntax and type errors and lint without running
+//	monkey lint [--json] <file> ...    report code that is likely a mistake
+//	monkey test [-run regexp] [dir/...] run the tests in *_test.monkey files
+//	monkey debug <file>          run a script in the step debugger
+//	monkey cover [--html] <profile> report the coverage recorded by run --coverprofile
+//	monkey doc [--html] [-o dir] <dir> write the docs of the functions in dir
+//	monkey dap [--listen addr]   serve the Debug Adapter Protocol
+//	monkey version               print the version
+//
+// A file of "-" means standard input. The exit status is 0 on success, 1
+// if the script fails while running and 2 for usage and syntax errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const (
+	exitOK = iota
+	exitRuntimeError
+	exitUsage
+)
+
+// errUsage is returned by commands that were called the wrong way; the
+// command's usage has already been printed.
+var errUsage = errors.New("usage error")
+
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(args []string) int
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"run", "<file> [args]", "run a script", runCmd},
+		{"repl", "", "start the interactive prompt", replCmd},
+		{"tokens", "<file> [--json]", "print the tokens of a script", tokensCmd},
+		{"ast", "<file> [--json]", "print the syntax tree of a script", astCmd},
+		{"check", "[--config file] <file>", "report syntax and type errors and lint findings without running the script", checkCmd},
+		{"lint", "[--json] [--config file] <file> ...", "report code that is likely a mistake", lintCmd},
+		{"test", "[-run regexp] [-v] [--junit file] [path ...]", "run the tests in *_test.monkey files", testCmd},
+		{"debug", "<file>", "run a script in the step debugger", debugCmd},
+		{"dap", "[--listen addr]", "serve the Debug Adapter Protocol for editors", dapCmd},
+		{"cover", "[--html] [-o file] <profile>", "report the coverage recorded by run --coverprofile", coverCmd},
+		{"doc", "[--html] [-o dir] <dir>", "write the documentation of the scripts in dir", docCmd},
+		{"version", "", "print the version", versionCmd},
+		{"help", "[command]", "print help for a command", helpCmd},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: monkey <command> [arguments]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "    %-8s %s\n", c.name, c.short)
+	}
+}
+
+func lookup(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	c := lookup(os.Args[1])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(exitUsage)
+	}
+	os.Exit(c.run(os.Args[2:]))
+}
+
+// newFlagSet returns the flag set for command name, which prints the
+// command's usage on errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		c := lookup(name)
+		fmt.Fprintf(os.Stderr, "usage: monkey %s %s\n", c.name, c.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags wherever they appear in args, so both
+// `ast --json f` and `ast f --json` work, and returns the other arguments.
+// Everything after a "--" is returned as is.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if len(args) > 0 && args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func helpCmd(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitOK
+	}
+	c := lookup(args[0])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", args[0])
+		return exitUsage
+	}
+	fmt.Fprintf(os.Stderr, "usage: monkey %s %s\n\n%s\n", c.name, c.args, c.short)
+	return exitOK
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the command itself, rather than the tests, when the tests
+// run the test binary as monkey.
+func TestMain(m *testing.M) {
+	if os.Getenv("MONKEY_TEST_MAIN") == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+// monkey runs the command with args in dir and returns what it printed
+// and its exit status.
+func monkey(t *testing.T, dir string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "MONKEY_TEST_MAIN=1")
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		status = exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), status
+}
+
+// writeFiles writes files, their contents by their slash-separated paths,
+// below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"add.monkey": `// add returns the sum of a and b.
+//
+// It fails on booleans.
+let add = fn(a, b) { a + b };
+
+help(add);
+`})
+	stdout, stderr, status := monkey(t, dir, "run", "add.monkey")
+	expected := "fn(a, b)\n    add returns the sum of a and b.\n\n    It fails on booleans.\nNULL\n"
+	if status != 0 || stdout != expected {
+		t.Fatalf("expected %q and status 0, got %q and %d (%s)", expected, stdout, status, stderr)
+	}
+}
+
+func TestDoc(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/math.monkey":     "// add returns a + b.\nlet add = fn(a, b) { a + b };\n",
+		"src/lib/strs.monkey": "let twice = fn(s) { s + s };\n",
+	})
+	if _, stderr, status := monkey(t, dir, "doc", "src"); status != 0 {
+		t.Fatalf("expected status 0, got %d (%s)", status, stderr)
+	}
+	for _, page := range []string{"index.md", "math.md", "lib/strs.md"} {
+		if _, err := os.Stat(filepath.Join(dir, "doc", filepath.FromSlash(page))); err != nil {
+			t.Errorf("expected page %s: %v", page, err)
+		}
+	}
+
+	_, stderr, status := monkey(t, dir, "doc", "-o", "filedoc", "src/math.monkey")
+	if expected := "monkey doc: src/math.monkey is not a directory\n"; status != 2 || stderr != expected {
+		t.Errorf("expected %q and status 2, got %q and %d", expected, stderr, status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "filedoc")); !os.IsNotExist(err) {
+		t.Errorf("expected no pages written for a file, got %v", err)
+	}
+}
+
+func TestRunDivisionByZero(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"div.monkey": "let x = 1 / 0;\nx\n"})
+	_, stderr, status := monkey(t, dir, "run", "div.monkey")
+	if expected := "div.monkey:1:11: division by zero\n"; status != 1 || stderr != expected {
+		t.Fatalf("expected %q and status 1, got %q and %d", expected, stderr, status)
+	}
+}
+
+func TestCheckLints(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"clean.monkey":  "let add = fn(a: int, b: int) -> int { a + b };\nadd(1, 2)\n",
+		"unused.monkey": "let f = fn() {\n    let x = 1;\n    2\n};\nf()\n",
+		"types.monkey":  "let x = 1;\nlet y: bool = 1;\n",
+		"off.json":      `{"rules": {"unused": false}}`,
+	})
+	input := []struct {
+		args   []string
+		stdout string
+		status int
+	}{
+		{[]string{"check", "clean.monkey"}, "", 0},
+		{[]string{"check", "unused.monkey"}, "unused.monkey:2:9: x is bound but never used (unused)\n", 1},
+		{[]string{"check", "--config", "off.json", "unused.monkey"}, "", 0},
+		// type errors are reported without the lint findings
+		{[]string{"check", "types.monkey"}, "", 2},
+	}
+	for _, tt := range input {
+		stdout, stderr, status := monkey(t, dir, tt.args...)
+		if stdout != tt.stdout || status != tt.status {
+			t.Errorf("%q: expected %q and status %d, got %q and %d (%s)", tt.args, tt.stdout, tt.status, stdout, status, stderr)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/cover"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/profile"
+	"github.com/myselfBZ/interpreter/internal/repl"
+)
+
+// runCmd runs a script and prints the value it ends with. Arguments after
+// the file are accepted for the script, but Monkey has no strings or
+// arrays to hand them over in yet.
+func runCmd(args []string) int {
+	fs := newFlagSet("run")
+	fromJSON := fs.Bool("from-json", false, "read the script as a JSON syntax tree, as written by ast --json")
+	cpuProfile := fs.String("cpuprofile", "", "write a pprof profile of the script's calls to `file`")
+	summary := fs.Bool("profile-summary", false, "print the calls and times of each function on standard error")
+	coverProfile := fs.String("coverprofile", "", "write the statements and branches that ran to `file`, for monkey cover")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *coverProfile != "" && (*cpuProfile != "" || *summary) {
+		fmt.Fprintln(os.Stderr, "monkey run: --coverprofile can't be used with --cpuprofile or --profile-summary")
+		return exitUsage
+	}
+	path := fs.Arg(0)
+	var program *ast.Program
+	var err error
+	if *fromJSON {
+		program, err = readJSONFile(path)
+	} else {
+		// the comments are kept for help() to show
+		program, err = parseFile(path, true)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	program, err = expandMacros(path, program)
+	if err != nil {
+		return fail(err)
+	}
+	env := object.NewEnviroment()
+	if err := resolve(path, program, env); err != nil {
+		return fail(err)
+	}
+	var result object.Object
+	switch {
+	case *cpuProfile != "" || *summary:
+		prof := profile.New(path)
+		result = prof.Run(program, env)
+		if err := writeProfile(prof, *cpuProfile, *summary); err != nil {
+			return fail(err)
+		}
+	case *coverProfile != "":
+		coverage := cover.New(path, program)
+		result = coverage.Run(program, env)
+		if err := writeCoverProfile(coverage, *coverProfile); err != nil {
+			return fail(err)
+		}
+	default:
+		result = evaluator.Eval(program, env)
+	}
+	if e, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, runtimeError(path, e))
+		return exitRuntimeError
+	}
+	if result != nil {
+		fmt.Println(result.Inspect())
+	}
+	return exitOK
+}
+
+// writeProfile writes what prof measured to the file at path, if there is
+// one, and the summary table to standard error if summary is set.
+func writeProfile(prof *profile.Profiler, path string, summary bool) error {
+	if summary {
+		if err := prof.WriteSummary(os.Stderr); err != nil {
+			return err
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := prof.WriteProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeCoverProfile writes the blocks coverage counted to the file at
+// path.
+func writeCoverProfile(coverage *cover.Coverage, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := cover.WriteProfile(f, coverage.Blocks()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func replCmd(args []string) int {
+	fs := newFlagSet("repl")
+	if _, err := parseArgs(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	repl.Start()
+	return exitOK
+}
+
+// runtimeError formats e as path:line:column: message.
+func runtimeError(path string, e *object.Error) string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", path, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", path, e.Message)
+}
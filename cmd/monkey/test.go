@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/myselfBZ/interpreter/internal/tester"
+)
+
+// testCmd runs the tests in the *_test.monkey files the arguments name,
+// the current directory by default, and reports them like `go test`.
+func testCmd(args []string) int {
+	fs := newFlagSet("test")
+	run := fs.String("run", "", "run only the tests whose names match `regexp`")
+	verbose := fs.Bool("v", false, "list every test, and print the output of the ones that pass too")
+	junit := fs.String("junit", "", "also write a JUnit XML report to `file`")
+	patterns, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	var match *regexp.Regexp
+	if *run != "" {
+		if match, err = regexp.Compile(*run); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey test: bad -run: %s\n", err)
+			return exitUsage
+		}
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	paths, err := tester.Find(patterns)
+	if err != nil {
+		return fail(err)
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "monkey test: no test files, their names end in "+tester.Suffix)
+		return exitUsage
+	}
+
+	status := exitOK
+	var files []*tester.File
+	for _, path := range paths {
+		f := tester.Run(path, match)
+		tester.WriteText(os.Stdout, f, *verbose)
+		if f.Failed() {
+			status = exitRuntimeError
+		}
+		files = append(files, f)
+	}
+	if *junit != "" {
+		out, err := os.Create(*junit)
+		if err != nil {
+			return fail(err)
+		}
+		err = tester.WriteJUnit(out, files)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return status
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// version is set at build time with -ldflags "-X main.version=...", and
+// otherwise taken from the module version if there is one.
+var version = ""
+
+func versionCmd(args []string) int {
+	v := version
+	if v == "" {
+		v = "devel"
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+	}
+	fmt.Printf("monkey %s %s/%s\n", v, runtime.GOOS, runtime.GOARCH)
+	return exitOK
+}
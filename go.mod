@@ -3,8 +3,9 @@ module github.com/myselfBZ/interpreter
 go 1.22.10
 
 require (
-	github.com/mattn/go-runewidth v0.0.3 // indirect
-	github.com/peterh/liner v1.2.2 // indirect
-	github.com/tidwall/pretty v1.2.1 // indirect
-	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
+	github.com/mattn/go-runewidth v0.0.3
+	github.com/peterh/liner v1.2.2
+	github.com/tidwall/pretty v1.2.1
 )
+
+require golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
@@ -24,6 +24,9 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+	// Comments holds the comments of the source in order, if the lexer was
+	// asked to emit them.
+	Comments []*token.Token
 }
 
 func (p *Program) String() string {
@@ -34,6 +37,90 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// CommentsBefore returns the comments directly above node, with no blank
+// line or code between them and node or each other.
+func (p *Program) CommentsBefore(node Node) []*token.Token {
+	return p.commentsBefore(node, p.codeLines())
+}
+
+// codeLines returns the lines of the program with code on them. Comments
+// that share their line with code belong to that code.
+func (p *Program) codeLines() map[int]bool {
+	code := map[int]bool{}
+	Inspect(p, func(n Node) bool {
+		if t := NodeToken(n); t != nil {
+			code[t.Line] = true
+		}
+		if b, ok := n.(*BlockStatement); ok && b.Rbrace != nil {
+			code[b.Rbrace.Line] = true
+		}
+		return true
+	})
+	return code
+}
+
+func (p *Program) commentsBefore(node Node, code map[int]bool) []*token.Token {
+	start := Start(node)
+	if start == nil {
+		return nil
+	}
+	line := start.Line
+	i := len(p.Comments)
+	for i > 0 && p.Comments[i-1].Line >= line {
+		i--
+	}
+	j := i
+	for j > 0 && CommentEndLine(p.Comments[j-1]) == line-1 && !code[p.Comments[j-1].Line] {
+		j--
+		line = p.Comments[j].Line
+	}
+	return p.Comments[j:i]
+}
+
+// Doc returns the text of the comments right above node, without the
+// comment markers.
+func (p *Program) Doc(node Node) string {
+	return docText(p.CommentsBefore(node))
+}
+
+// SetDocs sets the Doc of each function bound by a let at the top level of
+// the program to the comments right above the let.
+func (p *Program) SetDocs() {
+	if len(p.Comments) == 0 {
+		return
+	}
+	code := p.codeLines()
+	for _, stmt := range p.Statements {
+		if let, ok := stmt.(*LetStatement); ok {
+			if fn, ok := let.Value.(*FunctionLiteral); ok {
+				fn.Doc = docText(p.commentsBefore(let, code))
+			}
+		}
+	}
+}
+
+func docText(comments []*token.Token) string {
+	var lines []string
+	for _, c := range comments {
+		text := c.Literal
+		if strings.HasPrefix(text, "//") {
+			text = strings.TrimPrefix(text, "//")
+		} else {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		}
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// CommentEndLine returns the line a comment token ends on, which is later
+// than its start for block comments spanning several lines.
+func CommentEndLine(c *token.Token) int {
+	return c.Line + strings.Count(c.Literal, "\n")
+}
+
 func (p *Program) TokenLiteral() string {
 	if len(p.Statements) > 0 {
 		return p.Statements[0].TokenLiteral()
@@ -64,6 +151,18 @@ func (e *ExpressionStatement) TokenLiteral() string {
 type Identifier struct {
 	Token *token.Token `json:"token"`
 	Value string       `json:"value"`
+	// Type is the annotation of a let or a parameter, nil if it has none
+	Type Type `json:"type"`
+	// Ref is the slot the resolver found the binding of the identifier in,
+	// nil if it names a global or the resolver hasn't run
+	Ref *Ref `json:"-"`
+}
+
+// A Ref locates a binding made by a function call: slot Slot of the call
+// Depth functions out from the one the identifier is in.
+type Ref struct {
+	Depth int
+	Slot  int
 }
 
 func (i *Identifier) expressionNode() { return }
@@ -123,7 +222,11 @@ func (l *LetStatement) TokenLiteral() string {
 func (l *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(l.TokenLiteral() + " ")
-	out.WriteString(l.Name.String() + "=")
+	out.WriteString(l.Name.String())
+	if l.Name.Type != nil {
+		out.WriteString(": " + l.Name.Type.String())
+	}
+	out.WriteString("=")
 	if l.Value != nil {
 		out.WriteString(l.Value.String())
 	}
@@ -213,6 +316,7 @@ func (i *IfExpression) String() string {
 type BlockStatement struct {
 	Token      *token.Token // {
 	Statements []Statement
+	Rbrace     *token.Token // }, nil if the block was never closed
 }
 
 func (b *BlockStatement) statementNode() { return }
@@ -230,7 +334,15 @@ func (b *BlockStatement) String() string {
 type FunctionLiteral struct {
 	Token  *token.Token
 	Params []*Identifier
+	// Result is the annotation of what the function returns, or nil
+	Result Type
 	Body   *BlockStatement
+	// Locals are the names of the slots a call gets, its parameters and
+	// lets, as numbered by the resolver; nil if it hasn't run
+	Locals []string
+	// Doc is the text of the comments right above the top-level let that
+	// binds the function, if the parser kept comments
+	Doc string
 }
 
 func (f *FunctionLiteral) expressionNode() { return }
@@ -240,10 +352,17 @@ func (f *FunctionLiteral) String() string {
 	out.WriteString("(")
 	params := []string{}
 	for _, p := range f.Params {
-		params = append(params, p.String())
+		if p.Type != nil {
+			params = append(params, p.String()+": "+p.Type.String())
+		} else {
+			params = append(params, p.String())
+		}
 	}
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	if f.Result != nil {
+		out.WriteString("-> " + f.Result.String() + " ")
+	}
 	out.WriteString("{\n")
 	statements := []string{}
 	for _, s := range f.Body.Statements {
@@ -261,6 +380,7 @@ type Call struct {
 	Token     *token.Token // probably the name of the
 	Function  Expression
 	Arguments []Expression
+	Rparen    *token.Token // ), nil if the call was never closed
 }
 
 func (c *Call) expressionNode() {
@@ -281,3 +401,33 @@ func (c *Call) String() string {
 	out.WriteString(")")
 	return out.String()
 }
+
+type MacroLiteral struct {
+	Token  *token.Token
+	Params []*Identifier
+	Body   *BlockStatement
+}
+
+func (m *MacroLiteral) expressionNode() { return }
+func (m *MacroLiteral) String() string {
+	var out bytes.Buffer
+	out.WriteString(m.TokenLiteral() + " ")
+	out.WriteString("(")
+	params := []string{}
+	for _, p := range m.Params {
+		params = append(params, p.String())
+	}
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString("{\n")
+	statements := []string{}
+	for _, s := range m.Body.Statements {
+		statements = append(statements, s.String())
+	}
+	out.WriteString(strings.Join(statements, "\n"))
+	out.WriteString("\n}")
+	return out.String()
+}
+func (m *MacroLiteral) TokenLiteral() string {
+	return m.Token.Literal
+}
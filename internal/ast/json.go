@@ -0,0 +1,665 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// SchemaVersion is the version of the JSON format written by MarshalJSON.
+// It changes whenever a program written in the old format would be read
+// back differently.
+const SchemaVersion = 1
+
+// The JSON format wraps the tree in a document that carries the schema
+// version:
+//
+//	{"version": 1, "node": {"kind": "Program", "statements": [...], "comments": null}}
+//
+// Every node is an object with a "kind" naming its type, followed by its
+// fields. Tokens are objects with type, literal, line and column. Missing
+// children are null.
+type document struct {
+	Version int             `json:"version"`
+	Node    json.RawMessage `json:"node"`
+}
+
+type (
+	jsonProgram struct {
+		Kind       string            `json:"kind"`
+		Statements []json.RawMessage `json:"statements"`
+		Comments   []*token.Token    `json:"comments"`
+	}
+	jsonBlock struct {
+		Kind       string            `json:"kind"`
+		Token      *token.Token      `json:"token"`
+		Statements []json.RawMessage `json:"statements"`
+		Rbrace     *token.Token      `json:"rbrace"`
+	}
+	jsonExpressionStatement struct {
+		Kind       string          `json:"kind"`
+		Token      *token.Token    `json:"token"`
+		Expression json.RawMessage `json:"expression"`
+	}
+	jsonLet struct {
+		Kind  string          `json:"kind"`
+		Token *token.Token    `json:"token"`
+		Name  json.RawMessage `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	jsonReturn struct {
+		Kind        string          `json:"kind"`
+		Token       *token.Token    `json:"token"`
+		ReturnValue json.RawMessage `json:"returnValue"`
+	}
+	jsonInfix struct {
+		Kind     string          `json:"kind"`
+		Token    *token.Token    `json:"token"`
+		Operator string          `json:"operator"`
+		Left     json.RawMessage `json:"left"`
+		Right    json.RawMessage `json:"right"`
+	}
+	jsonPrefix struct {
+		Kind     string          `json:"kind"`
+		Token    *token.Token    `json:"token"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	jsonIf struct {
+		Kind        string          `json:"kind"`
+		Token       *token.Token    `json:"token"`
+		Condition   json.RawMessage `json:"condition"`
+		Consequence json.RawMessage `json:"consequence"`
+		Alternative json.RawMessage `json:"alternative"`
+	}
+	// jsonFunction is used for both function and macro literals
+	jsonFunction struct {
+		Kind   string            `json:"kind"`
+		Token  *token.Token      `json:"token"`
+		Params []json.RawMessage `json:"params"`
+		Body   json.RawMessage   `json:"body"`
+		// Result is the annotated result type of a function, omitted if
+		// there's none
+		Result json.RawMessage `json:"result,omitempty"`
+		Doc    string          `json:"doc,omitempty"`
+	}
+	jsonCall struct {
+		Kind      string            `json:"kind"`
+		Token     *token.Token      `json:"token"`
+		Function  json.RawMessage   `json:"function"`
+		Arguments []json.RawMessage `json:"arguments"`
+		Rparen    *token.Token      `json:"rparen"`
+	}
+	jsonIdentifier struct {
+		Kind  string       `json:"kind"`
+		Token *token.Token `json:"token"`
+		Value string       `json:"value"`
+		// Type is the annotated type of a let or a parameter, omitted if
+		// there's none
+		Type json.RawMessage `json:"type,omitempty"`
+	}
+	jsonNamedType struct {
+		Kind  string       `json:"kind"`
+		Token *token.Token `json:"token"`
+		Name  string       `json:"name"`
+	}
+	jsonFunctionType struct {
+		Kind   string            `json:"kind"`
+		Token  *token.Token      `json:"token"`
+		Params []json.RawMessage `json:"params"`
+		Result json.RawMessage   `json:"result"`
+	}
+	jsonUnionType struct {
+		Kind  string            `json:"kind"`
+		Types []json.RawMessage `json:"types"`
+	}
+	jsonInt struct {
+		Kind  string       `json:"kind"`
+		Token *token.Token `json:"token"`
+		Value int64        `json:"value"`
+	}
+	jsonBoolean struct {
+		Kind  string       `json:"kind"`
+		Token *token.Token `json:"token"`
+		Value bool         `json:"value"`
+	}
+)
+
+// MarshalJSON encodes the tree rooted at node in the versioned JSON format.
+func MarshalJSON(node Node) ([]byte, error) {
+	raw, err := encode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(document{Version: SchemaVersion, Node: raw})
+}
+
+// UnmarshalJSON decodes a tree written by MarshalJSON, or by any other tool
+// that follows the format.
+func UnmarshalJSON(data []byte) (Node, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != SchemaVersion {
+		return nil, fmt.Errorf("ast: unsupported schema version %d, want %d", doc.Version, SchemaVersion)
+	}
+	return decode(doc.Node)
+}
+
+var null = json.RawMessage("null")
+
+func encode(node Node) (json.RawMessage, error) {
+	var v interface{}
+	var err error
+	switch n := node.(type) {
+	case nil:
+		return null, nil
+	case *Program:
+		j := jsonProgram{Kind: "Program", Comments: n.Comments}
+		j.Statements, err = encodeStatements(n.Statements)
+		v = j
+	case *BlockStatement:
+		if n == nil {
+			return null, nil
+		}
+		j := jsonBlock{Kind: "BlockStatement", Token: n.Token, Rbrace: n.Rbrace}
+		j.Statements, err = encodeStatements(n.Statements)
+		v = j
+	case *ExpressionStatement:
+		j := jsonExpressionStatement{Kind: "ExpressionStatement", Token: n.Token}
+		j.Expression, err = encodeExpression(n.Expression)
+		v = j
+	case *LetStatement:
+		j := jsonLet{Kind: "LetStatement", Token: n.Token}
+		if j.Name, err = encodeIdentifier(n.Name); err == nil {
+			j.Value, err = encodeExpression(n.Value)
+		}
+		v = j
+	case *ReturnStatement:
+		j := jsonReturn{Kind: "ReturnStatement", Token: n.Token}
+		j.ReturnValue, err = encodeExpression(n.ReturnValue)
+		v = j
+	case *InfixExperssion:
+		j := jsonInfix{Kind: "InfixExpression", Token: n.Token, Operator: n.Operator}
+		if j.Left, err = encodeExpression(n.Left); err == nil {
+			j.Right, err = encodeExpression(n.Right)
+		}
+		v = j
+	case *PrefixExpression:
+		j := jsonPrefix{Kind: "PrefixExpression", Token: n.Token, Operator: n.Operator}
+		j.Right, err = encodeExpression(n.Right)
+		v = j
+	case *IfExpression:
+		j := jsonIf{Kind: "IfExpression", Token: n.Token}
+		if j.Condition, err = encodeExpression(n.Condition); err == nil {
+			if j.Consequence, err = encode(n.Consequence); err == nil {
+				j.Alternative, err = encode(n.Alternative)
+			}
+		}
+		v = j
+	case *FunctionLiteral:
+		j := jsonFunction{Kind: "FunctionLiteral", Token: n.Token, Doc: n.Doc}
+		if j.Params, err = encodeIdentifiers(n.Params); err == nil {
+			if j.Body, err = encode(n.Body); err == nil && n.Result != nil {
+				j.Result, err = encode(n.Result)
+			}
+		}
+		v = j
+	case *MacroLiteral:
+		j := jsonFunction{Kind: "MacroLiteral", Token: n.Token}
+		if j.Params, err = encodeIdentifiers(n.Params); err == nil {
+			j.Body, err = encode(n.Body)
+		}
+		v = j
+	case *Call:
+		j := jsonCall{Kind: "Call", Token: n.Token, Rparen: n.Rparen}
+		if j.Function, err = encodeExpression(n.Function); err == nil && n.Arguments != nil {
+			j.Arguments = make([]json.RawMessage, len(n.Arguments))
+			for i, a := range n.Arguments {
+				if j.Arguments[i], err = encodeExpression(a); err != nil {
+					break
+				}
+			}
+		}
+		v = j
+	case *Identifier:
+		if n == nil {
+			return null, nil
+		}
+		j := jsonIdentifier{Kind: "Identifier", Token: n.Token, Value: n.Value}
+		if n.Type != nil {
+			j.Type, err = encode(n.Type)
+		}
+		v = j
+	case *NamedType:
+		v = jsonNamedType{Kind: "NamedType", Token: n.Token, Name: n.Name}
+	case *FunctionType:
+		j := jsonFunctionType{Kind: "FunctionType", Token: n.Token}
+		if j.Params, err = encodeTypes(n.Params); err == nil {
+			j.Result, err = encodeType(n.Result)
+		}
+		v = j
+	case *UnionType:
+		j := jsonUnionType{Kind: "UnionType"}
+		j.Types, err = encodeTypes(n.Types)
+		v = j
+	case *IntLiteral:
+		v = jsonInt{Kind: "IntLiteral", Token: n.Token, Value: n.Value}
+	case *Boolean:
+		v = jsonBoolean{Kind: "Boolean", Token: n.Token, Value: n.Value}
+	default:
+		return nil, fmt.Errorf("ast: can't encode node of type %T", node)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func encodeStatements(stmts []Statement) ([]json.RawMessage, error) {
+	if stmts == nil {
+		return nil, nil
+	}
+	raw := make([]json.RawMessage, len(stmts))
+	for i, s := range stmts {
+		var err error
+		if s == nil {
+			raw[i] = null
+		} else if raw[i], err = encode(s); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func encodeExpression(e Expression) (json.RawMessage, error) {
+	if e == nil {
+		return null, nil
+	}
+	return encode(e)
+}
+
+func encodeIdentifier(i *Identifier) (json.RawMessage, error) {
+	if i == nil {
+		return null, nil
+	}
+	return encode(i)
+}
+
+func encodeIdentifiers(idents []*Identifier) ([]json.RawMessage, error) {
+	if idents == nil {
+		return nil, nil
+	}
+	raw := make([]json.RawMessage, len(idents))
+	for i, ident := range idents {
+		var err error
+		if raw[i], err = encodeIdentifier(ident); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func encodeType(t Type) (json.RawMessage, error) {
+	if t == nil {
+		return null, nil
+	}
+	return encode(t)
+}
+
+func encodeTypes(types []Type) ([]json.RawMessage, error) {
+	if types == nil {
+		return nil, nil
+	}
+	raw := make([]json.RawMessage, len(types))
+	for i, t := range types {
+		var err error
+		if raw[i], err = encodeType(t); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// tokenKinds are the kinds of nodes that carry a token, which the
+// evaluator and the passes before it use for positions.
+var tokenKinds = map[string]bool{
+	"BlockStatement": true, "ExpressionStatement": true, "LetStatement": true,
+	"ReturnStatement": true, "InfixExpression": true, "PrefixExpression": true,
+	"IfExpression": true, "FunctionLiteral": true, "MacroLiteral": true,
+	"Call": true, "Identifier": true, "NamedType": true, "FunctionType": true,
+	"IntLiteral": true, "Boolean": true,
+}
+
+// errMissing reports a node that lacks a part it can't run without, like
+// a let without a name. The parser never makes those.
+func errMissing(kind, part string) error {
+	return fmt.Errorf("ast: %s without %s", kind, part)
+}
+
+func decode(raw json.RawMessage) (Node, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var header struct {
+		Kind  string          `json:"kind"`
+		Token json.RawMessage `json:"token"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+	if tokenKinds[header.Kind] && isNull(header.Token) {
+		return nil, errMissing(header.Kind, "a token")
+	}
+	var err error
+	switch header.Kind {
+	case "Program":
+		var j jsonProgram
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &Program{Comments: j.Comments}
+		n.Statements, err = decodeStatements(j.Statements)
+		return n, err
+	case "BlockStatement":
+		return decodeBlockRaw(raw)
+	case "ExpressionStatement":
+		var j jsonExpressionStatement
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &ExpressionStatement{Token: j.Token}
+		if n.Expression, err = decodeExpression(j.Expression); err == nil && n.Expression == nil {
+			err = errMissing(header.Kind, "an expression")
+		}
+		return n, err
+	case "LetStatement":
+		var j jsonLet
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &LetStatement{Token: j.Token}
+		if n.Name, err = decodeIdentifier(j.Name); err != nil {
+			return nil, err
+		}
+		if n.Name == nil {
+			return nil, errMissing(header.Kind, "a name")
+		}
+		if n.Value, err = decodeExpression(j.Value); err == nil && n.Value == nil {
+			err = errMissing(header.Kind, "a value")
+		}
+		return n, err
+	case "ReturnStatement":
+		var j jsonReturn
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &ReturnStatement{Token: j.Token}
+		if n.ReturnValue, err = decodeExpression(j.ReturnValue); err == nil && n.ReturnValue == nil {
+			err = errMissing(header.Kind, "a value")
+		}
+		return n, err
+	case "InfixExpression":
+		var j jsonInfix
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &InfixExperssion{Token: j.Token, Operator: j.Operator}
+		if n.Left, err = decodeExpression(j.Left); err != nil {
+			return nil, err
+		}
+		if n.Right, err = decodeExpression(j.Right); err != nil {
+			return nil, err
+		}
+		if n.Left == nil || n.Right == nil {
+			return nil, errMissing(header.Kind, "an operand")
+		}
+		return n, nil
+	case "PrefixExpression":
+		var j jsonPrefix
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &PrefixExpression{Token: j.Token, Operator: j.Operator}
+		if n.Right, err = decodeExpression(j.Right); err == nil && n.Right == nil {
+			err = errMissing(header.Kind, "an operand")
+		}
+		return n, err
+	case "IfExpression":
+		var j jsonIf
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &IfExpression{Token: j.Token}
+		if n.Condition, err = decodeExpression(j.Condition); err != nil {
+			return nil, err
+		}
+		if n.Condition == nil {
+			return nil, errMissing(header.Kind, "a condition")
+		}
+		if n.Consequence, err = decodeBlock(j.Consequence); err != nil {
+			return nil, err
+		}
+		if n.Consequence == nil {
+			return nil, errMissing(header.Kind, "a consequence")
+		}
+		n.Alternative, err = decodeBlock(j.Alternative)
+		return n, err
+	case "FunctionLiteral", "MacroLiteral":
+		var j jsonFunction
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		params, err := decodeIdentifiers(j.Params)
+		if err != nil {
+			return nil, err
+		}
+		body, err := decodeBlock(j.Body)
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			return nil, errMissing(header.Kind, "a body")
+		}
+		if header.Kind == "MacroLiteral" {
+			return &MacroLiteral{Token: j.Token, Params: params, Body: body}, nil
+		}
+		result, err := decodeType(j.Result)
+		if err != nil {
+			return nil, err
+		}
+		return &FunctionLiteral{Token: j.Token, Params: params, Body: body, Result: result, Doc: j.Doc}, nil
+	case "Call":
+		var j jsonCall
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &Call{Token: j.Token, Rparen: j.Rparen}
+		if n.Function, err = decodeExpression(j.Function); err != nil {
+			return nil, err
+		}
+		if n.Function == nil {
+			return nil, errMissing(header.Kind, "a function")
+		}
+		if j.Arguments != nil {
+			n.Arguments = make([]Expression, len(j.Arguments))
+			for i, a := range j.Arguments {
+				if n.Arguments[i], err = decodeExpression(a); err != nil {
+					return nil, err
+				}
+				if n.Arguments[i] == nil {
+					return nil, errMissing(header.Kind, "an argument")
+				}
+			}
+		}
+		return n, nil
+	case "Identifier":
+		var j jsonIdentifier
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &Identifier{Token: j.Token, Value: j.Value}
+		n.Type, err = decodeType(j.Type)
+		return n, err
+	case "NamedType":
+		var j jsonNamedType
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		return &NamedType{Token: j.Token, Name: j.Name}, nil
+	case "FunctionType":
+		var j jsonFunctionType
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &FunctionType{Token: j.Token}
+		if n.Params, err = decodeTypes(j.Params); err != nil {
+			return nil, err
+		}
+		n.Result, err = decodeType(j.Result)
+		return n, err
+	case "UnionType":
+		var j jsonUnionType
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		n := &UnionType{}
+		n.Types, err = decodeTypes(j.Types)
+		return n, err
+	case "IntLiteral":
+		var j jsonInt
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		return &IntLiteral{Token: j.Token, Value: j.Value}, nil
+	case "Boolean":
+		var j jsonBoolean
+		if err = json.Unmarshal(raw, &j); err != nil {
+			return nil, err
+		}
+		return &Boolean{Token: j.Token, Value: j.Value}, nil
+	case "":
+		return nil, fmt.Errorf("ast: node without a kind: %s", raw)
+	}
+	return nil, fmt.Errorf("ast: unknown node kind %q", header.Kind)
+}
+
+func decodeStatements(raw []json.RawMessage) ([]Statement, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	stmts := make([]Statement, len(raw))
+	for i, r := range raw {
+		n, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			return nil, fmt.Errorf("ast: null statement")
+		}
+		s, ok := n.(Statement)
+		if !ok {
+			return nil, fmt.Errorf("ast: expected a statement, got %T", n)
+		}
+		stmts[i] = s
+	}
+	return stmts, nil
+}
+
+func decodeExpression(raw json.RawMessage) (Expression, error) {
+	n, err := decode(raw)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	e, ok := n.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected an expression, got %T", n)
+	}
+	return e, nil
+}
+
+func decodeBlockRaw(raw json.RawMessage) (*BlockStatement, error) {
+	var j jsonBlock
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return nil, err
+	}
+	if j.Kind != "BlockStatement" {
+		return nil, fmt.Errorf("ast: expected a BlockStatement, got %q", j.Kind)
+	}
+	stmts, err := decodeStatements(j.Statements)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockStatement{Token: j.Token, Statements: stmts, Rbrace: j.Rbrace}, nil
+}
+
+func decodeBlock(raw json.RawMessage) (*BlockStatement, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	return decodeBlockRaw(raw)
+}
+
+func decodeIdentifier(raw json.RawMessage) (*Identifier, error) {
+	n, err := decode(raw)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	i, ok := n.(*Identifier)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected an Identifier, got %T", n)
+	}
+	return i, nil
+}
+
+func decodeIdentifiers(raw []json.RawMessage) ([]*Identifier, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	idents := make([]*Identifier, len(raw))
+	for i, r := range raw {
+		var err error
+		if idents[i], err = decodeIdentifier(r); err != nil {
+			return nil, err
+		}
+		if idents[i] == nil {
+			return nil, fmt.Errorf("ast: null parameter")
+		}
+	}
+	return idents, nil
+}
+
+func decodeType(raw json.RawMessage) (Type, error) {
+	n, err := decode(raw)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	t, ok := n.(Type)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected a type, got %T", n)
+	}
+	return t, nil
+}
+
+func decodeTypes(raw []json.RawMessage) ([]Type, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	types := make([]Type, len(raw))
+	for i, r := range raw {
+		var err error
+		if types[i], err = decodeType(r); err != nil {
+			return nil, err
+		}
+		if types[i] == nil {
+			return nil, fmt.Errorf("ast: null type")
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,100 @@
+package ast
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	program := sampleProgram()
+	data, err := MarshalJSON(program)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %s", err)
+	}
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON: %s", err)
+	}
+	if !reflect.DeepEqual(node, program) {
+		t.Fatalf("round trip changed the program:\n%s\n%s", program.String(), node.String())
+	}
+	again, err := MarshalJSON(node)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %s", err)
+	}
+	if string(again) != string(data) {
+		t.Fatalf("encoding is not stable:\n%s\n%s", data, again)
+	}
+}
+
+func TestJSONHasKindOnEveryNode(t *testing.T) {
+	data, err := MarshalJSON(sampleProgram())
+	if err != nil {
+		t.Fatalf("MarshalJSON: %s", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+	if doc["version"] != float64(SchemaVersion) {
+		t.Fatalf("expected version %d got %v", SchemaVersion, doc["version"])
+	}
+	kinds := map[string]bool{}
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if kind, ok := v["kind"].(string); ok {
+				kinds[kind] = true
+			}
+			for _, child := range v {
+				collect(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				collect(child)
+			}
+		}
+	}
+	collect(doc["node"])
+	for _, name := range nodeTypes(t) {
+		kind := strings.Replace(name, "Experssion", "Expression", 1)
+		if !kinds[kind] {
+			t.Errorf("no node of kind %s in the JSON; handle %s in encode and decode", kind, name)
+		}
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tok := `{"type": "IDENT", "literal": "x", "line": 1, "column": 1}`
+	x := `{"kind": "Identifier", "token": ` + tok + `, "value": "x"}`
+	one := `{"kind": "IntLiteral", "token": ` + tok + `, "value": 1}`
+	block := `{"kind": "BlockStatement", "token": ` + tok + `, "statements": []}`
+	input := []string{
+		`{"version": 2, "node": null}`,
+		`{"version": 1, "node": {"kind": "Nope"}}`,
+		`{"version": 1, "node": {"statements": []}}`,
+		`{"version": 1, "node": {"kind": "Program", "statements": [{"kind": "IntLiteral", "value": 1}]}}`,
+		`{"version": 1, "node": {"kind": "LetStatement", "name": {"kind": "IntLiteral", "value": 1}}}`,
+		// parts the evaluator can't do without
+		`{"version": 1, "node": {"kind": "Identifier", "value": "x"}}`,
+		`{"version": 1, "node": {"kind": "LetStatement", "token": ` + tok + `, "name": null, "value": ` + one + `}}`,
+		`{"version": 1, "node": {"kind": "LetStatement", "token": ` + tok + `, "name": ` + x + `, "value": null}}`,
+		`{"version": 1, "node": {"kind": "IfExpression", "token": ` + tok + `, "condition": null, "consequence": ` + block + `}}`,
+		`{"version": 1, "node": {"kind": "IfExpression", "token": ` + tok + `, "condition": ` + one + `}}`,
+		`{"version": 1, "node": {"kind": "FunctionLiteral", "token": ` + tok + `, "params": []}}`,
+		`{"version": 1, "node": {"kind": "FunctionLiteral", "token": ` + tok + `, "params": [null], "body": ` + block + `}}`,
+		`{"version": 1, "node": {"kind": "MacroLiteral", "token": ` + tok + `, "params": [], "body": null}}`,
+		`{"version": 1, "node": {"kind": "Call", "token": ` + tok + `, "function": null, "arguments": []}}`,
+		`{"version": 1, "node": {"kind": "Call", "token": ` + tok + `, "function": ` + x + `, "arguments": [null]}}`,
+		`{"version": 1, "node": {"kind": "InfixExpression", "token": ` + tok + `, "operator": "+", "left": ` + one + `}}`,
+		`{"version": 1, "node": {"kind": "Program", "statements": [null]}}`,
+	}
+	for _, tt := range input {
+		if _, err := UnmarshalJSON([]byte(tt)); err == nil {
+			t.Errorf("%s: expected an error", tt)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ast
+
+import "github.com/myselfBZ/interpreter/internal/token"
+
+// NodeToken returns the token node was built from. For infix expressions
+// that's the operator and for calls the opening parenthesis; use Start to
+// get the first token in the source.
+func NodeToken(node Node) *token.Token {
+	switch n := node.(type) {
+	case *Program:
+		if len(n.Statements) > 0 {
+			return NodeToken(n.Statements[0])
+		}
+	case *BlockStatement:
+		return n.Token
+	case *ExpressionStatement:
+		return n.Token
+	case *LetStatement:
+		return n.Token
+	case *ReturnStatement:
+		return n.Token
+	case *InfixExperssion:
+		return n.Token
+	case *PrefixExpression:
+		return n.Token
+	case *IfExpression:
+		return n.Token
+	case *FunctionLiteral:
+		return n.Token
+	case *MacroLiteral:
+		return n.Token
+	case *Call:
+		return n.Token
+	case *Identifier:
+		return n.Token
+	case *IntLiteral:
+		return n.Token
+	case *Boolean:
+		return n.Token
+	case *NamedType:
+		return n.Token
+	case *FunctionType:
+		return n.Token
+	case *UnionType:
+		if len(n.Types) > 0 {
+			return NodeToken(n.Types[0])
+		}
+	}
+	return nil
+}
+
+// Start returns the leftmost token of node, or nil if it has none.
+func Start(node Node) *token.Token {
+	switch n := node.(type) {
+	case *Program:
+		if len(n.Statements) > 0 {
+			return Start(n.Statements[0])
+		}
+		return nil
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			return Start(n.Expression)
+		}
+	case *InfixExperssion:
+		if n.Left != nil {
+			return Start(n.Left)
+		}
+	case *Call:
+		if n.Function != nil {
+			return Start(n.Function)
+		}
+	}
+	return NodeToken(node)
+}
+
+// End returns the rightmost token of node that is kept in the tree. Closing
+// braces and the parentheses closing calls are, other parentheses and
+// semicolons are not.
+func End(node Node) *token.Token {
+	var end *token.Token
+	switch n := node.(type) {
+	case *Program:
+		if len(n.Statements) > 0 {
+			end = End(n.Statements[len(n.Statements)-1])
+		}
+	case *BlockStatement:
+		if n.Rbrace != nil {
+			return n.Rbrace
+		}
+		if len(n.Statements) > 0 {
+			end = End(n.Statements[len(n.Statements)-1])
+		}
+	case *ExpressionStatement:
+		end = endOf(n.Expression)
+	case *LetStatement:
+		end = endOf(n.Value)
+	case *ReturnStatement:
+		end = endOf(n.ReturnValue)
+	case *InfixExperssion:
+		end = endOf(n.Right)
+	case *PrefixExpression:
+		end = endOf(n.Right)
+	case *IfExpression:
+		if n.Alternative != nil {
+			end = End(n.Alternative)
+		} else if n.Consequence != nil {
+			end = End(n.Consequence)
+		}
+	case *FunctionLiteral:
+		if n.Body != nil {
+			end = End(n.Body)
+		}
+	case *MacroLiteral:
+		if n.Body != nil {
+			end = End(n.Body)
+		}
+	case *Call:
+		if n.Rparen != nil {
+			return n.Rparen
+		}
+		if len(n.Arguments) > 0 {
+			end = endOf(n.Arguments[len(n.Arguments)-1])
+		}
+	}
+	if end == nil {
+		return NodeToken(node)
+	}
+	return end
+}
+
+func endOf(e Expression) *token.Token {
+	if e == nil {
+		return nil
+	}
+	return End(e)
+}
@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// A Type is a type annotation, on a let, a parameter or the result of a
+// function. The evaluator ignores them; `monkey check` holds the program
+// to them.
+type Type interface {
+	Node
+	typeNode()
+}
+
+// NamedType is a type written as a name, like int or bool.
+type NamedType struct {
+	Token *token.Token
+	Name  string
+}
+
+func (n *NamedType) typeNode() {}
+
+func (n *NamedType) TokenLiteral() string {
+	return n.Token.Literal
+}
+
+func (n *NamedType) String() string {
+	return n.Name
+}
+
+// FunctionType is the type of functions, like fn(int, int) -> bool.
+type FunctionType struct {
+	Token  *token.Token
+	Params []Type
+	Result Type
+}
+
+func (f *FunctionType) typeNode() {}
+
+func (f *FunctionType) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FunctionType) String() string {
+	params := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		params[i] = p.String()
+	}
+	result := "?"
+	if f.Result != nil {
+		result = f.Result.String()
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + result
+}
+
+// UnionType is the type of values of any of Types, like int | bool.
+type UnionType struct {
+	Types []Type
+}
+
+func (u *UnionType) typeNode() {}
+
+func (u *UnionType) TokenLiteral() string {
+	return u.Types[0].TokenLiteral()
+}
+
+func (u *UnionType) String() string {
+	types := make([]string, len(u.Types))
+	for i, t := range u.Types {
+		types[i] = t.String()
+		// the result of a function type would take in the rest
+		if _, ok := t.(*FunctionType); ok {
+			types[i] = "(" + types[i] + ")"
+		}
+	}
+	return strings.Join(types, " | ")
+}
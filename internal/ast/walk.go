@@ -0,0 +1,388 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is called for every node Walk reaches. If it
+// returns a non-nil Visitor w, Walk visits the children of node with w and
+// then calls w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node depth first, in source order.
+// It panics on node types it doesn't know about, so a new node type can't
+// be skipped silently.
+func Walk(node Node, v Visitor) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(n.Statements, v)
+	case *BlockStatement:
+		walkStatements(n.Statements, v)
+	case *ExpressionStatement:
+		walkExpression(n.Expression, v)
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(n.Name, v)
+		}
+		walkExpression(n.Value, v)
+	case *ReturnStatement:
+		walkExpression(n.ReturnValue, v)
+	case *InfixExperssion:
+		walkExpression(n.Left, v)
+		walkExpression(n.Right, v)
+	case *PrefixExpression:
+		walkExpression(n.Right, v)
+	case *IfExpression:
+		walkExpression(n.Condition, v)
+		if n.Consequence != nil {
+			Walk(n.Consequence, v)
+		}
+		if n.Alternative != nil {
+			Walk(n.Alternative, v)
+		}
+	case *FunctionLiteral:
+		walkIdentifiers(n.Params, v)
+		walkType(n.Result, v)
+		if n.Body != nil {
+			Walk(n.Body, v)
+		}
+	case *MacroLiteral:
+		walkIdentifiers(n.Params, v)
+		if n.Body != nil {
+			Walk(n.Body, v)
+		}
+	case *Call:
+		walkExpression(n.Function, v)
+		for _, a := range n.Arguments {
+			walkExpression(a, v)
+		}
+	case *Identifier:
+		walkType(n.Type, v)
+	case *FunctionType:
+		for _, p := range n.Params {
+			walkType(p, v)
+		}
+		walkType(n.Result, v)
+	case *UnionType:
+		for _, t := range n.Types {
+			walkType(t, v)
+		}
+	case *IntLiteral, *Boolean, *NamedType:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+	v.Visit(nil)
+}
+
+func walkStatements(stmts []Statement, v Visitor) {
+	for _, s := range stmts {
+		if s != nil {
+			Walk(s, v)
+		}
+	}
+}
+
+func walkExpression(e Expression, v Visitor) {
+	if e != nil {
+		Walk(e, v)
+	}
+}
+
+func walkIdentifiers(idents []*Identifier, v Visitor) {
+	for _, i := range idents {
+		if i != nil {
+			Walk(i, v)
+		}
+	}
+}
+
+func walkType(t Type, v Visitor) {
+	if t != nil {
+		Walk(t, v)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node and calls f for every node.
+// If f returns false the children of that node are skipped. After the
+// children of a node have been visited f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+// ModifierFunc returns the node that should take the place of node.
+type ModifierFunc func(node Node) Node
+
+// Modify calls modifier on every node in the tree rooted at node, children
+// first, and puts the result in place of the original. The tree is changed
+// in place; use Clone first to keep the original. A replacement of the
+// wrong kind (a statement where an expression goes, for example) is
+// ignored and the old node is kept.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = modifyStatements(n.Statements, modifier)
+	case *BlockStatement:
+		n.Statements = modifyStatements(n.Statements, modifier)
+	case *ExpressionStatement:
+		n.Expression = modifyExpression(n.Expression, modifier)
+	case *LetStatement:
+		if n.Name != nil {
+			n.Name = modifyIdentifier(n.Name, modifier)
+		}
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ReturnStatement:
+		n.ReturnValue = modifyExpression(n.ReturnValue, modifier)
+	case *InfixExperssion:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Right = modifyExpression(n.Right, modifier)
+	case *PrefixExpression:
+		n.Right = modifyExpression(n.Right, modifier)
+	case *IfExpression:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		n.Alternative = modifyBlock(n.Alternative, modifier)
+	case *FunctionLiteral:
+		for i, p := range n.Params {
+			n.Params[i] = modifyIdentifier(p, modifier)
+		}
+		n.Result = modifyType(n.Result, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *MacroLiteral:
+		for i, p := range n.Params {
+			n.Params[i] = modifyIdentifier(p, modifier)
+		}
+		n.Body = modifyBlock(n.Body, modifier)
+	case *Call:
+		n.Function = modifyExpression(n.Function, modifier)
+		for i, a := range n.Arguments {
+			n.Arguments[i] = modifyExpression(a, modifier)
+		}
+	case *Identifier:
+		n.Type = modifyType(n.Type, modifier)
+	case *FunctionType:
+		for i, p := range n.Params {
+			n.Params[i] = modifyType(p, modifier)
+		}
+		n.Result = modifyType(n.Result, modifier)
+	case *UnionType:
+		for i, t := range n.Types {
+			n.Types[i] = modifyType(t, modifier)
+		}
+	case *IntLiteral, *Boolean, *NamedType:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Modify: unexpected node type %T", n))
+	}
+	return modifier(node)
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) []Statement {
+	for i, s := range stmts {
+		if s == nil {
+			continue
+		}
+		if m, ok := Modify(s, modifier).(Statement); ok {
+			stmts[i] = m
+		}
+	}
+	return stmts
+}
+
+func modifyExpression(e Expression, modifier ModifierFunc) Expression {
+	if e == nil {
+		return nil
+	}
+	if m, ok := Modify(e, modifier).(Expression); ok {
+		return m
+	}
+	return e
+}
+
+func modifyBlock(b *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	if m, ok := Modify(b, modifier).(*BlockStatement); ok {
+		return m
+	}
+	return b
+}
+
+func modifyIdentifier(i *Identifier, modifier ModifierFunc) *Identifier {
+	if i == nil {
+		return nil
+	}
+	if m, ok := Modify(i, modifier).(*Identifier); ok {
+		return m
+	}
+	return i
+}
+
+func modifyType(t Type, modifier ModifierFunc) Type {
+	if t == nil {
+		return nil
+	}
+	if m, ok := Modify(t, modifier).(Type); ok {
+		return m
+	}
+	return t
+}
+
+// Clone returns a deep copy of the tree rooted at node. Tokens are shared
+// with the original since nothing changes them in place.
+func Clone(node Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		c := *n
+		c.Statements = cloneStatements(n.Statements)
+		return &c
+	case *BlockStatement:
+		c := *n
+		c.Statements = cloneStatements(n.Statements)
+		return &c
+	case *ExpressionStatement:
+		c := *n
+		c.Expression = cloneExpression(n.Expression)
+		return &c
+	case *LetStatement:
+		c := *n
+		if n.Name != nil {
+			c.Name = Clone(n.Name).(*Identifier)
+		}
+		c.Value = cloneExpression(n.Value)
+		return &c
+	case *ReturnStatement:
+		c := *n
+		c.ReturnValue = cloneExpression(n.ReturnValue)
+		return &c
+	case *InfixExperssion:
+		c := *n
+		c.Left = cloneExpression(n.Left)
+		c.Right = cloneExpression(n.Right)
+		return &c
+	case *PrefixExpression:
+		c := *n
+		c.Right = cloneExpression(n.Right)
+		return &c
+	case *IfExpression:
+		c := *n
+		c.Condition = cloneExpression(n.Condition)
+		c.Consequence = cloneBlock(n.Consequence)
+		c.Alternative = cloneBlock(n.Alternative)
+		return &c
+	case *FunctionLiteral:
+		c := *n
+		c.Params = cloneIdentifiers(n.Params)
+		c.Result = cloneType(n.Result)
+		c.Body = cloneBlock(n.Body)
+		return &c
+	case *MacroLiteral:
+		c := *n
+		c.Params = cloneIdentifiers(n.Params)
+		c.Body = cloneBlock(n.Body)
+		return &c
+	case *Call:
+		c := *n
+		c.Function = cloneExpression(n.Function)
+		c.Arguments = make([]Expression, len(n.Arguments))
+		for i, a := range n.Arguments {
+			c.Arguments[i] = cloneExpression(a)
+		}
+		return &c
+	case *Identifier:
+		c := *n
+		c.Type = cloneType(n.Type)
+		return &c
+	case *IntLiteral:
+		c := *n
+		return &c
+	case *Boolean:
+		c := *n
+		return &c
+	case *NamedType:
+		c := *n
+		return &c
+	case *FunctionType:
+		c := *n
+		c.Params = cloneTypes(n.Params)
+		c.Result = cloneType(n.Result)
+		return &c
+	case *UnionType:
+		c := *n
+		c.Types = cloneTypes(n.Types)
+		return &c
+	default:
+		panic(fmt.Sprintf("ast.Clone: unexpected node type %T", n))
+	}
+}
+
+func cloneStatements(stmts []Statement) []Statement {
+	if stmts == nil {
+		return nil
+	}
+	c := make([]Statement, len(stmts))
+	for i, s := range stmts {
+		if s != nil {
+			c[i] = Clone(s).(Statement)
+		}
+	}
+	return c
+}
+
+func cloneExpression(e Expression) Expression {
+	if e == nil {
+		return nil
+	}
+	return Clone(e).(Expression)
+}
+
+func cloneBlock(b *BlockStatement) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	return Clone(b).(*BlockStatement)
+}
+
+func cloneIdentifiers(idents []*Identifier) []*Identifier {
+	if idents == nil {
+		return nil
+	}
+	c := make([]*Identifier, len(idents))
+	for i, ident := range idents {
+		if ident != nil {
+			c[i] = Clone(ident).(*Identifier)
+		}
+	}
+	return c
+}
+
+func cloneType(t Type) Type {
+	if t == nil {
+		return nil
+	}
+	return Clone(t).(Type)
+}
+
+func cloneTypes(types []Type) []Type {
+	if types == nil {
+		return nil
+	}
+	c := make([]Type, len(types))
+	for i, t := range types {
+		c[i] = cloneType(t)
+	}
+	return c
+}
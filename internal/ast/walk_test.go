@@ -0,0 +1,207 @@
+package ast
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	mtoken "github.com/myselfBZ/interpreter/internal/token"
+)
+
+// nodeTypes returns the name of every type in this package that implements
+// Node, read from the source so a new node type shows up here on its own.
+func nodeTypes(t *testing.T) []string {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatalf("reading package ast: %s", err)
+	}
+	fset := token.NewFileSet()
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, e.Name(), nil, 0)
+		if err != nil {
+			t.Fatalf("parsing %s: %s", e.Name(), err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "TokenLiteral" {
+				continue
+			}
+			star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+			if !ok {
+				continue
+			}
+			seen[star.X.(*ast.Ident).Name] = true
+		}
+	}
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tok(kind mtoken.TokenType, literal string) *mtoken.Token {
+	t := mtoken.NewToken(kind, literal)
+	return &t
+}
+
+func ident(name string) *Identifier {
+	return &Identifier{Token: tok(mtoken.IDENT, name), Value: name}
+}
+
+func intLit(v int64) *IntLiteral {
+	return &IntLiteral{Token: tok(mtoken.INT, fmt.Sprint(v)), Value: v}
+}
+
+// sampleProgram contains at least one node of every type. When a node type
+// is added, extend it, then teach Walk, Modify and Clone about the new type.
+func sampleProgram() *Program {
+	block := func(stmts ...Statement) *BlockStatement {
+		return &BlockStatement{Token: tok(mtoken.LBRACE, "{"), Statements: stmts}
+	}
+	return &Program{Statements: []Statement{
+		&LetStatement{Token: tok(mtoken.LET, "let"), Name: ident("f"), Value: &FunctionLiteral{
+			Token:  tok(mtoken.FUNCTION, "fn"),
+			Params: []*Identifier{{Token: tok(mtoken.IDENT, "x"), Value: "x", Type: &NamedType{Token: tok(mtoken.IDENT, "int"), Name: "int"}}},
+			Result: &UnionType{Types: []Type{
+				&FunctionType{Token: tok(mtoken.FUNCTION, "fn"), Params: []Type{}, Result: &NamedType{Token: tok(mtoken.IDENT, "int"), Name: "int"}},
+				&NamedType{Token: tok(mtoken.IDENT, "null"), Name: "null"},
+			}},
+			Body: block(&ReturnStatement{Token: tok(mtoken.RETURN, "return"), ReturnValue: &InfixExperssion{
+				Token: tok(mtoken.PLUS, "+"), Operator: "+", Left: ident("x"), Right: intLit(1),
+			}}),
+		}},
+		&LetStatement{Token: tok(mtoken.LET, "let"), Name: ident("m"), Value: &MacroLiteral{
+			Token:  tok(mtoken.MACRO, "macro"),
+			Params: []*Identifier{ident("y")},
+			Body:   block(&ExpressionStatement{Token: tok(mtoken.IDENT, "y"), Expression: ident("y")}),
+		}},
+		&ExpressionStatement{Token: tok(mtoken.IF, "if"), Expression: &IfExpression{
+			Token:     tok(mtoken.IF, "if"),
+			Condition: &PrefixExpression{Token: tok(mtoken.BANG, "!"), Operator: "!", Right: &Boolean{Token: tok(mtoken.TRUE, "true"), Value: true}},
+			Consequence: block(&ExpressionStatement{Token: tok(mtoken.IDENT, "f"), Expression: &Call{
+				Token: tok(mtoken.LPAREN, "("), Function: ident("f"), Arguments: []Expression{intLit(2)},
+			}}),
+			Alternative: block(&ExpressionStatement{Token: tok(mtoken.INT, "3"), Expression: intLit(3)}),
+		}},
+	}}
+}
+
+func typeName(n Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+func TestInspectVisitsEveryNodeType(t *testing.T) {
+	visited := map[string]bool{}
+	Inspect(sampleProgram(), func(n Node) bool {
+		if n != nil {
+			visited[typeName(n)] = true
+		}
+		return true
+	})
+	for _, name := range nodeTypes(t) {
+		if !visited[name] {
+			t.Errorf("Inspect never reached a %s; add one to sampleProgram and handle it in Walk", name)
+		}
+	}
+}
+
+func TestModifyVisitsEveryNodeType(t *testing.T) {
+	visited := map[string]bool{}
+	Modify(sampleProgram(), func(n Node) Node {
+		visited[typeName(n)] = true
+		return n
+	})
+	for _, name := range nodeTypes(t) {
+		if !visited[name] {
+			t.Errorf("Modify never reached a %s; handle it in Modify", name)
+		}
+	}
+}
+
+func TestCloneCopiesEveryNode(t *testing.T) {
+	program := sampleProgram()
+	original := map[Node]bool{}
+	Inspect(program, func(n Node) bool {
+		if n != nil {
+			original[n] = true
+		}
+		return true
+	})
+	clone := Clone(program)
+	if clone.String() != program.String() {
+		t.Fatalf("clone differs: %q vs %q", clone.String(), program.String())
+	}
+	Inspect(clone, func(n Node) bool {
+		if n != nil && original[n] {
+			t.Errorf("Clone shares a %s with the original", typeName(n))
+		}
+		return true
+	})
+}
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return intLit(1) }
+	two := func() Expression { return intLit(2) }
+	turnOneIntoTwo := func(n Node) Node {
+		i, ok := n.(*IntLiteral)
+		if !ok || i.Value != 1 {
+			return n
+		}
+		return intLit(2)
+	}
+	input := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&InfixExperssion{Left: one(), Operator: "+", Right: two()},
+			&InfixExperssion{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&Call{Function: ident("f"), Arguments: []Expression{one(), one()}},
+			&Call{Function: ident("f"), Arguments: []Expression{two(), two()}},
+		},
+	}
+	for _, tt := range input {
+		modified := Modify(tt.input, turnOneIntoTwo)
+		if modified.String() != tt.expected.String() {
+			t.Fatalf("expected %s got %s", tt.expected.String(), modified.String())
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	var idents []string
+	Inspect(sampleProgram(), func(n Node) bool {
+		if _, ok := n.(*FunctionLiteral); ok {
+			return false
+		}
+		if i, ok := n.(*Identifier); ok {
+			idents = append(idents, i.Value)
+		}
+		return true
+	})
+	if strings.Join(idents, " ") != "f m y y f" {
+		t.Fatalf("unexpected identifiers %v", idents)
+	}
+}
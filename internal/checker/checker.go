@@ -0,0 +1,359 @@
+// Package checker finds type errors in Monkey programs before they run.
+//
+// Annotations on lets, parameters and function results say what types
+// values must have, and the checker infers the types of everything else
+// from literals, operators, ifs and calls. What it can't know, like an
+// unannotated parameter, has type any and is allowed everywhere, so
+// programs without annotations are only held to what is certain to fail,
+// like adding an integer to a boolean.
+package checker
+
+import (
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// An Error is a type error, at the line and column of the token it is
+// about.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Check returns the type errors in program, in the order it found them.
+// Macros have to be expanded first.
+func Check(program *ast.Program) []*Error {
+	c := &checker{scope: newScope(nil)}
+	for _, s := range program.Statements {
+		if s != nil {
+			c.statement(s)
+		}
+	}
+	return c.errors
+}
+
+type checker struct {
+	errors []*Error
+	scope  *scope
+	// fn is the function whose body is being checked, nil at the top
+	// level
+	fn *function
+}
+
+type function struct {
+	// result is the annotated result type, nil if there's none
+	result Type
+	// returns are the types of the values the function returns
+	returns []Type
+}
+
+// A scope holds the types of the names bound in a function, or at the top
+// level. Blocks share the scope they are in, as they share the
+// enviroment when the program runs.
+type scope struct {
+	names map[string]Type
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{names: map[string]Type{}, outer: outer}
+}
+
+func (s *scope) lookup(name string) (Type, bool) {
+	for ; s != nil; s = s.outer {
+		if t, ok := s.names[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (c *checker) errorf(tok *token.Token, format string, a ...interface{}) {
+	e := &Error{Message: fmt.Sprintf(format, a...)}
+	if tok != nil {
+		e.Line, e.Column = tok.Line, tok.Column
+	}
+	c.errors = append(c.errors, e)
+}
+
+// statement checks s and returns the type of the value it leaves, which
+// is what a block ending with it evaluates to.
+func (c *checker) statement(s ast.Statement) Type {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		return c.expr(s.Expression)
+	case *ast.LetStatement:
+		c.let(s)
+		return Null
+	case *ast.ReturnStatement:
+		t := c.expr(s.ReturnValue)
+		if c.fn != nil {
+			if c.fn.result != nil && !assignable(t, c.fn.result) {
+				c.errorf(s.Token, "can't return %s from a function that returns %s", t, c.fn.result)
+			}
+			c.fn.returns = append(c.fn.returns, t)
+		}
+		return never
+	case *ast.BlockStatement:
+		return c.block(s)
+	}
+	return Any
+}
+
+func (c *checker) let(s *ast.LetStatement) {
+	if s.Name == nil {
+		c.expr(s.Value)
+		return
+	}
+	var declared Type
+	if s.Name.Type != nil {
+		declared = c.resolve(s.Name.Type)
+	}
+	var t Type
+	if fn, ok := s.Value.(*ast.FunctionLiteral); ok {
+		// the parameters of a declared function type stand in for missing
+		// parameter annotations
+		hint, _ := declared.(*Func)
+		f := c.signature(fn, hint)
+		// a function can call itself through the name it is bound to, so
+		// the name is bound before the function is checked
+		if declared != nil {
+			c.scope.names[s.Name.Value] = declared
+		} else {
+			c.scope.names[s.Name.Value] = f
+		}
+		t = c.function(fn, f)
+	} else {
+		t = c.expr(s.Value)
+	}
+	if declared == nil {
+		c.scope.names[s.Name.Value] = t
+		return
+	}
+	if !assignable(t, declared) {
+		c.errorf(ast.Start(s.Value), "%s is declared %s, can't bind it to %s", s.Name.Value, declared, t)
+	}
+	c.scope.names[s.Name.Value] = declared
+}
+
+// block checks the statements of b and returns the type of its value.
+func (c *checker) block(b *ast.BlockStatement) Type {
+	if b == nil {
+		return Null
+	}
+	t := Null
+	for _, s := range b.Statements {
+		if s == nil {
+			continue
+		}
+		st := c.statement(s)
+		// whatever follows a return never runs, so it has no say in the
+		// value of the block
+		if !isNever(t) {
+			t = st
+		}
+	}
+	return t
+}
+
+// expr checks e and returns its type.
+func (c *checker) expr(e ast.Expression) Type {
+	switch e := e.(type) {
+	case nil:
+		return Any
+	case *ast.IntLiteral:
+		return Int
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		if t, ok := c.scope.lookup(e.Value); ok {
+			return t
+		}
+		// builtins and names the program never binds; the latter fail
+		// when the program runs
+		return Any
+	case *ast.PrefixExpression:
+		return c.prefix(e)
+	case *ast.InfixExperssion:
+		return c.infix(e)
+	case *ast.IfExpression:
+		cond := c.expr(e.Condition)
+		if !assignable(cond, Bool) {
+			c.errorf(e.Token, "non-boolean condition in if: %s", cond)
+		}
+		consequence := c.block(e.Consequence)
+		alternative := Null
+		if e.Alternative != nil {
+			alternative = c.block(e.Alternative)
+		}
+		return union(consequence, alternative)
+	case *ast.FunctionLiteral:
+		return c.function(e, c.signature(e, nil))
+	case *ast.MacroLiteral:
+		return Any
+	case *ast.Call:
+		return c.call(e)
+	}
+	return Any
+}
+
+func (c *checker) prefix(e *ast.PrefixExpression) Type {
+	right := c.expr(e.Right)
+	if e.Operator == "!" {
+		return Bool
+	}
+	if right == Any {
+		return Any
+	}
+	// minus turns anything but an integer into null
+	results := make([]Type, 0, len(members(right)))
+	for _, m := range members(right) {
+		if m == Int {
+			results = append(results, Int)
+		} else {
+			results = append(results, Null)
+		}
+	}
+	return union(results...)
+}
+
+// infix checks that the operator of e is defined on every pair of types
+// its operands may have, as the evaluator defines it: arithmetic and
+// comparisons on integers, and == and != on booleans too.
+func (c *checker) infix(e *ast.InfixExperssion) Type {
+	left := c.expr(e.Left)
+	right := c.expr(e.Right)
+	result := Bool
+	switch e.Operator {
+	case "+", "-", "*", "/":
+		result = Int
+	}
+	for _, l := range members(left) {
+		for _, r := range members(right) {
+			if l == Any || r == Any || (l == Int && r == Int) {
+				continue
+			}
+			if kind(l) != kind(r) {
+				c.errorf(e.Token, "mismatched types %s and %s for %s", left, right, e.Operator)
+				return result
+			}
+			if l != Bool || (e.Operator != "==" && e.Operator != "!=") {
+				c.errorf(e.Token, "operator %s isn't defined on %s", e.Operator, kind(l))
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// signature returns the type of fn as its annotations give it, with any
+// for what they leave out. The parameters of expected, a function type,
+// stand in for the missing parameter annotations.
+func (c *checker) signature(fn *ast.FunctionLiteral, expected *Func) *Func {
+	f := &Func{Params: make([]Type, len(fn.Params)), Result: Any}
+	for i, p := range fn.Params {
+		switch {
+		case p == nil:
+			f.Params[i] = Any
+		case p.Type != nil:
+			f.Params[i] = c.resolve(p.Type)
+		case expected != nil && len(expected.Params) == len(fn.Params):
+			f.Params[i] = expected.Params[i]
+		default:
+			f.Params[i] = Any
+		}
+	}
+	if fn.Result != nil {
+		f.Result = c.resolve(fn.Result)
+	}
+	return f
+}
+
+// function checks the body of fn, whose signature is f, and returns its
+// type: f with the result inferred from what the body returns, unless it
+// is annotated.
+func (c *checker) function(fn *ast.FunctionLiteral, f *Func) Type {
+	outerScope, outerFn := c.scope, c.fn
+	defer func() { c.scope, c.fn = outerScope, outerFn }()
+	c.scope = newScope(outerScope)
+	for i, p := range fn.Params {
+		if p != nil {
+			c.scope.names[p.Value] = f.Params[i]
+		}
+	}
+	c.fn = &function{}
+	if fn.Result != nil {
+		c.fn.result = f.Result
+	}
+
+	body := c.block(fn.Body)
+	if !isNever(body) {
+		if c.fn.result != nil && !assignable(body, c.fn.result) {
+			c.errorf(lastToken(fn), "can't return %s from a function that returns %s", body, c.fn.result)
+		}
+		c.fn.returns = append(c.fn.returns, body)
+	}
+	if c.fn.result == nil {
+		f.Result = union(c.fn.returns...)
+	}
+	return f
+}
+
+// lastToken returns the token to report a function's value at: the start
+// of its last statement, or the function itself if its body is empty.
+func lastToken(fn *ast.FunctionLiteral) *token.Token {
+	if fn.Body != nil {
+		for i := len(fn.Body.Statements) - 1; i >= 0; i-- {
+			if s := fn.Body.Statements[i]; s != nil {
+				return ast.Start(s)
+			}
+		}
+	}
+	return fn.Token
+}
+
+func (c *checker) call(e *ast.Call) Type {
+	// the argument of quote is code, not a value
+	if ident, ok := e.Function.(*ast.Identifier); ok && ident.Value == "quote" && len(e.Arguments) == 1 {
+		return Any
+	}
+	callee := c.expr(e.Function)
+	args := make([]Type, len(e.Arguments))
+	for i, a := range e.Arguments {
+		args[i] = c.expr(a)
+	}
+	name := "function"
+	if ident, ok := e.Function.(*ast.Identifier); ok {
+		name = ident.Value
+	}
+
+	var results []Type
+	for _, m := range members(callee) {
+		if m == Any {
+			return Any
+		}
+		f, ok := m.(*Func)
+		if !ok {
+			c.errorf(e.Token, "not a function: %s is %s", name, callee)
+			return Any
+		}
+		if len(args) != len(f.Params) {
+			c.errorf(e.Token, "wrong number of arguments to %s: want %d got %d", name, len(f.Params), len(args))
+			return f.Result
+		}
+		for i, a := range args {
+			if !assignable(a, f.Params[i]) {
+				c.errorf(ast.Start(e.Arguments[i]), "can't use %s as %s in argument %d to %s", a, f.Params[i], i+1, name)
+			}
+		}
+		results = append(results, f.Result)
+	}
+	return union(results...)
+}
@@ -0,0 +1,140 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+func check(t *testing.T, src string) []string {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: unexpected syntax errors %v", src, p.Errors())
+	}
+	var errs []string
+	for _, e := range Check(program) {
+		errs = append(errs, e.Error())
+	}
+	return errs
+}
+
+func TestCheck(t *testing.T) {
+	input := []struct {
+		input    string
+		expected []string
+	}{
+		// unannotated code is only held to what can't work
+		{"let add = fn(a, b) { a + b }; add(1, true); add(1)", []string{"1:48: wrong number of arguments to add: want 2 got 1"}},
+		{"let x = 1; let y = true; x + y", []string{"1:28: mismatched types int and bool for +"}},
+		{"true < false", []string{"1:6: operator < isn't defined on bool"}},
+		{"let f = fn() { 1 }; f == f", []string{"1:23: operator == isn't defined on fn"}},
+		{"if (1) { 2 }", []string{"1:1: non-boolean condition in if: int"}},
+		{"let x = 1; x(2)", []string{"1:13: not a function: x is int"}},
+		{"puts(1); quote(1 + true); len(1)", nil},
+		{"let f = fn(x) { -x }; f(true) + 1", nil},
+
+		// inference through lets, ifs and function results
+		{"let x = if (true) { 1 } else { false }; x + 1", []string{"1:43: mismatched types int | bool and int for +"}},
+		{"let x = if (true) { 1 }; x * 2", []string{"1:28: mismatched types int | null and int for *"}},
+		{"let f = fn() { 1 }; f() == true", []string{"1:25: mismatched types int and bool for =="}},
+		{"let f = fn(n) { if (n) { return 1; } false }; f(true) + 1", []string{"1:55: mismatched types int | bool and int for +"}},
+		{"let n = -true; n + 1", []string{"1:18: mismatched types null and int for +"}},
+
+		// annotations
+		{"let x: int = 5; let y: bool = x == 5;", nil},
+		{"let x: bool = 5;", []string{"1:15: x is declared bool, can't bind it to int"}},
+		{"let x: int | null = 5; x + 1", []string{"1:26: mismatched types int | null and int for +"}},
+		{"let x: any = true; x + 1", nil},
+		{"let x: str = 1;", []string{"1:8: unknown type str"}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, true); add(1)", []string{
+			"1:55: can't use bool as int in argument 2 to add",
+			"1:65: wrong number of arguments to add: want 2 got 1",
+		}},
+		{"let f = fn(a: int) -> bool { a }", []string{"1:30: can't return int from a function that returns bool"}},
+		{"let f = fn(a: int) -> bool { if (a > 0) { return a; } true }", []string{"1:43: can't return int from a function that returns bool"}},
+		{"let f = fn() -> int { }", []string{"1:9: can't return null from a function that returns int"}},
+		{"let f = fn() -> int { return 1; 2 == true }", []string{"1:35: mismatched types int and bool for =="}},
+		{"let fib = fn(n: int) -> int { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(true)", []string{
+			"1:87: can't use bool as int in argument 1 to fib",
+		}},
+		{"let fib = fn(n: int) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(3) == true", []string{
+			"1:83: mismatched types int and bool for ==",
+		}},
+
+		// function types
+		{"let twice = fn(f: fn(int) -> int, x: int) -> int { f(f(x)) }; twice(fn(x) { x + 1 }, 1)", nil},
+		{"let twice = fn(f: fn(int) -> int, x: int) -> int { f(f(x)) }; twice(fn(x) { true }, 1)", []string{
+			"1:69: can't use fn(any) -> bool as fn(int) -> int in argument 1 to twice",
+		}},
+		{"let f: fn(int) -> int = fn(x) { x == true };", []string{
+			"1:35: mismatched types int and bool for ==",
+			"1:25: f is declared fn(int) -> int, can't bind it to fn(int) -> bool",
+		}},
+		{"let f: fn(int) -> bool = fn(x: any) -> bool { true };", nil},
+		{"let f: fn(int) -> any = fn(x: bool) { x };", []string{"1:25: f is declared fn(int) -> any, can't bind it to fn(bool) -> bool"}},
+		{"let f: (fn() -> int) | int = 1; f()", []string{"1:34: not a function: f is (fn() -> int) | int"}},
+
+		// lets in blocks bind in the enclosing function, as they do when
+		// the program runs
+		{"if (true) { let x = 1; } x + true", []string{"1:28: mismatched types int and bool for +"}},
+		{"let f = fn(x: int) { x }; let g = fn() { let x = true; x }; g() + 1", []string{"1:65: mismatched types bool and int for +"}},
+	}
+	for _, tt := range input {
+		got := check(t, tt.input)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: expected %q got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	f := &Func{Params: []Type{Int}, Result: Bool}
+	input := []struct {
+		types    []Type
+		expected string
+	}{
+		{nil, "never"},
+		{[]Type{Int}, "int"},
+		{[]Type{Int, Int, Bool}, "int | bool"},
+		{[]Type{Int, union(Bool, Null), Int}, "int | bool | null"},
+		{[]Type{never, Bool}, "bool"},
+		{[]Type{Int, Any}, "any"},
+		{[]Type{f, Null}, "(fn(int) -> bool) | null"},
+	}
+	for _, tt := range input {
+		if got := union(tt.types...).String(); got != tt.expected {
+			t.Errorf("union%v: expected %s got %s", tt.types, tt.expected, got)
+		}
+	}
+}
+
+func TestAssignable(t *testing.T) {
+	intOrNull := union(Int, Null)
+	input := []struct {
+		from, to Type
+		expected bool
+	}{
+		{Int, Int, true},
+		{Int, Bool, false},
+		{Any, Bool, true},
+		{Bool, Any, true},
+		{Int, intOrNull, true},
+		{intOrNull, Int, false},
+		{union(Null, Int), intOrNull, true},
+		{never, Int, true},
+		{&Func{Params: []Type{Any}, Result: Int}, &Func{Params: []Type{Int}, Result: intOrNull}, true},
+		{&Func{Params: []Type{Int}, Result: Int}, &Func{Params: []Type{intOrNull}, Result: Int}, false},
+		{&Func{Params: []Type{Int}, Result: intOrNull}, &Func{Params: []Type{Int}, Result: Int}, false},
+		{&Func{Params: []Type{}, Result: Int}, &Func{Params: []Type{Int}, Result: Int}, false},
+		{&Func{Params: []Type{}, Result: Int}, Int, false},
+	}
+	for _, tt := range input {
+		if got := assignable(tt.from, tt.to); got != tt.expected {
+			t.Errorf("assignable(%s, %s): expected %v got %v", tt.from, tt.to, tt.expected, got)
+		}
+	}
+}
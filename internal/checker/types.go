@@ -0,0 +1,195 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+)
+
+// A Type is what the checker knows about the values an expression may
+// have.
+type Type interface {
+	String() string
+}
+
+// basic is one of the types that have a name of their own.
+type basic string
+
+func (b basic) String() string { return string(b) }
+
+var (
+	Int  Type = basic("int")
+	Bool Type = basic("bool")
+	Null Type = basic("null")
+	// Any is the type of values the checker knows nothing about, like
+	// unannotated parameters. It goes with every other type, so code
+	// without annotations stays dynamically typed.
+	Any Type = basic("any")
+)
+
+// names are the types annotations can name.
+var names = map[string]Type{"int": Int, "bool": Bool, "null": Null, "any": Any}
+
+// Func is the type of functions.
+type Func struct {
+	Params []Type
+	Result Type
+}
+
+func (f *Func) String() string {
+	params := make([]string, len(f.Params))
+	for i, p := range f.Params {
+		params[i] = p.String()
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.Result.String()
+}
+
+// Union is the type of values of any of its types. Build them with union,
+// which keeps them flat and without repeats.
+type Union []Type
+
+func (u Union) String() string {
+	if len(u) == 0 {
+		return "never"
+	}
+	types := make([]string, len(u))
+	for i, t := range u {
+		types[i] = t.String()
+		if _, ok := t.(*Func); ok {
+			types[i] = "(" + types[i] + ")"
+		}
+	}
+	return strings.Join(types, " | ")
+}
+
+// never is the type of expressions that don't produce a value, like a
+// block that returns: the empty union.
+var never Type = Union{}
+
+func isNever(t Type) bool {
+	u, ok := t.(Union)
+	return ok && len(u) == 0
+}
+
+// union returns the type of values of any of types. It is Any if one of
+// them is, never if there are none and the type itself if there is just
+// one.
+func union(types ...Type) Type {
+	var u Union
+	seen := map[string]bool{}
+	var add func(t Type) bool
+	add = func(t Type) bool {
+		switch t := t.(type) {
+		case Union:
+			for _, m := range t {
+				if !add(m) {
+					return false
+				}
+			}
+		default:
+			if t == Any {
+				return false
+			}
+			if !seen[t.String()] {
+				seen[t.String()] = true
+				u = append(u, t)
+			}
+		}
+		return true
+	}
+	for _, t := range types {
+		if !add(t) {
+			return Any
+		}
+	}
+	if len(u) == 1 {
+		return u[0]
+	}
+	return u
+}
+
+// members returns the types t is a union of, or t itself.
+func members(t Type) []Type {
+	if u, ok := t.(Union); ok {
+		return u
+	}
+	return []Type{t}
+}
+
+// assignable reports whether a value of type from can be used where one of
+// type to is wanted.
+func assignable(from, to Type) bool {
+	if from == Any || to == Any {
+		return true
+	}
+	if u, ok := from.(Union); ok {
+		for _, m := range u {
+			if !assignable(m, to) {
+				return false
+			}
+		}
+		return true
+	}
+	if u, ok := to.(Union); ok {
+		for _, m := range u {
+			if assignable(from, m) {
+				return true
+			}
+		}
+		return false
+	}
+	f, ok := from.(*Func)
+	if !ok {
+		return from == to
+	}
+	g, ok := to.(*Func)
+	if !ok || len(f.Params) != len(g.Params) {
+		return false
+	}
+	// a function can stand in for another if it takes whatever the other
+	// takes and returns only what the other returns
+	for i := range f.Params {
+		if !assignable(g.Params[i], f.Params[i]) {
+			return false
+		}
+	}
+	return assignable(f.Result, g.Result)
+}
+
+// kind is the runtime type of the values of a type that isn't a union,
+// which decides what operators do with them.
+func kind(t Type) string {
+	if _, ok := t.(*Func); ok {
+		return "fn"
+	}
+	return t.String()
+}
+
+// resolve returns the type an annotation stands for, and reports the names
+// in it that aren't types.
+func (c *checker) resolve(t ast.Type) Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		if named, ok := names[t.Name]; ok {
+			return named
+		}
+		c.errorf(t.Token, "unknown type %s", t.Name)
+		return Any
+	case *ast.FunctionType:
+		f := &Func{Params: make([]Type, len(t.Params)), Result: Any}
+		for i, p := range t.Params {
+			f.Params[i] = c.resolve(p)
+		}
+		if t.Result != nil {
+			f.Result = c.resolve(t.Result)
+		}
+		return f
+	case *ast.UnionType:
+		types := make([]Type, len(t.Types))
+		for i, m := range t.Types {
+			types[i] = c.resolve(m)
+		}
+		return union(types...)
+	}
+	return Any
+}
@@ -0,0 +1,287 @@
+// Package cover records which statements of a Monkey program ran, and
+// which way its if expressions went, and reads and writes the results as
+// coverage profiles.
+package cover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// What a block counts.
+const (
+	// KindStatement counts the runs of a statement.
+	KindStatement = "stmt"
+	// KindThen and KindElse count how often an if expression went each
+	// way. An if without an else still has an else branch, which spans
+	// the if keyword.
+	KindThen = "then"
+	KindElse = "else"
+)
+
+// A Block is a piece of source and how many times it ran. Lines and
+// columns start at 1 and the end column is just past the block.
+type Block struct {
+	Path      string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	Kind      string
+	Count     int
+}
+
+// Coverage follows a program as it runs, counting its blocks.
+type Coverage struct {
+	path       string
+	blocks     []*Block
+	statements map[ast.Statement]*Block
+	branches   map[*ast.IfExpression][2]*Block
+}
+
+// New returns a Coverage for program, read from the file at path, with a
+// block for every statement and branch in it.
+func New(path string, program *ast.Program) *Coverage {
+	c := &Coverage{
+		path:       path,
+		statements: map[ast.Statement]*Block{},
+		branches:   map[*ast.IfExpression][2]*Block{},
+	}
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Program, *ast.BlockStatement:
+		case ast.Statement:
+			if b := c.block(ast.Start(n), ast.End(n), KindStatement); b != nil {
+				c.statements[n] = b
+			}
+		case *ast.IfExpression:
+			then := c.block(ast.Start(n.Consequence), ast.End(n.Consequence), KindThen)
+			var otherwise *Block
+			if n.Alternative != nil {
+				otherwise = c.block(ast.Start(n.Alternative), ast.End(n.Alternative), KindElse)
+			} else {
+				otherwise = c.block(n.Token, n.Token, KindElse)
+			}
+			c.branches[n] = [2]*Block{then, otherwise}
+		}
+		return true
+	})
+	return c
+}
+
+// block adds a block of kind from start to the end of end.
+func (c *Coverage) block(start, end *token.Token, kind string) *Block {
+	if start == nil || end == nil {
+		return nil
+	}
+	b := &Block{
+		Path:      c.path,
+		StartLine: start.Line,
+		StartCol:  start.Column,
+		EndLine:   end.Line,
+		EndCol:    end.Column + len([]rune(end.Literal)),
+		Kind:      kind,
+	}
+	c.blocks = append(c.blocks, b)
+	return b
+}
+
+// Run evaluates program in env while counting and returns what it
+// evaluates to. Only one program can be covered at a time, since the
+// Coverage is the evaluator's hook while it runs.
+func (c *Coverage) Run(program *ast.Program, env *object.Enviroment) object.Object {
+	previous := evaluator.SetHook(c)
+	defer evaluator.SetHook(previous)
+	return evaluator.Eval(program, env)
+}
+
+// Statement implements evaluator.Hook and counts stmt.
+func (c *Coverage) Statement(stmt ast.Statement, env *object.Enviroment) {
+	if b := c.statements[stmt]; b != nil {
+		b.Count++
+	}
+}
+
+// Call and Return implement evaluator.Hook; calls don't matter here.
+func (c *Coverage) Call(call *ast.Call, fn object.Object)       {}
+func (c *Coverage) Return(call *ast.Call, result object.Object) {}
+
+// Branch implements evaluator.BranchHook and counts the branch taken.
+func (c *Coverage) Branch(node *ast.IfExpression, consequence bool) {
+	branches, ok := c.branches[node]
+	if !ok {
+		return
+	}
+	b := branches[1]
+	if consequence {
+		b = branches[0]
+	}
+	if b != nil {
+		b.Count++
+	}
+}
+
+// Blocks returns the blocks in source order. Blocks of the same kind that
+// span the same source, like the code a macro expands to at two call
+// sites, are merged.
+func (c *Coverage) Blocks() []*Block {
+	return Merge(c.blocks)
+}
+
+// Merge returns blocks sorted in source order, with blocks of the same
+// kind over the same source merged into one with the sum of their counts.
+func Merge(blocks []*Block) []*Block {
+	sorted := make([]*Block, len(blocks))
+	copy(sorted, blocks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].less(sorted[j])
+	})
+	var merged []*Block
+	for _, b := range sorted {
+		if n := len(merged); n > 0 && merged[n-1].same(b) {
+			merged[n-1].Count += b.Count
+			continue
+		}
+		copied := *b
+		merged = append(merged, &copied)
+	}
+	return merged
+}
+
+func (b *Block) less(o *Block) bool {
+	if b.Path != o.Path {
+		return b.Path < o.Path
+	}
+	if b.StartLine != o.StartLine {
+		return b.StartLine < o.StartLine
+	}
+	if b.StartCol != o.StartCol {
+		return b.StartCol < o.StartCol
+	}
+	// the outer block first
+	if b.EndLine != o.EndLine {
+		return b.EndLine > o.EndLine
+	}
+	if b.EndCol != o.EndCol {
+		return b.EndCol > o.EndCol
+	}
+	return b.Kind < o.Kind
+}
+
+func (b *Block) same(o *Block) bool {
+	return b.Path == o.Path && b.StartLine == o.StartLine && b.StartCol == o.StartCol &&
+		b.EndLine == o.EndLine && b.EndCol == o.EndCol && b.Kind == o.Kind
+}
+
+// The first line of a profile. Each line after it is a block:
+//
+//	path:startLine.startCol,endLine.endCol kind count
+const header = "mode: count"
+
+// WriteProfile writes blocks as a coverage profile.
+func WriteProfile(w io.Writer, blocks []*Block) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, header)
+	for _, b := range blocks {
+		fmt.Fprintf(bw, "%s:%d.%d,%d.%d %s %d\n", b.Path, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.Kind, b.Count)
+	}
+	return bw.Flush()
+}
+
+// ReadProfile reads the blocks of a coverage profile written by
+// WriteProfile, merged.
+func ReadProfile(r io.Reader) ([]*Block, error) {
+	s := bufio.NewScanner(r)
+	if !s.Scan() || s.Text() != header {
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return nil, fmt.Errorf("not a coverage profile, the first line should be %q", header)
+	}
+	var blocks []*Block
+	for n := 2; s.Scan(); n++ {
+		line := s.Text()
+		// profiles of several runs can be concatenated
+		if line == "" || line == header {
+			continue
+		}
+		b, err := parseBlock(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		blocks = append(blocks, b)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return Merge(blocks), nil
+}
+
+func parseBlock(line string) (*Block, error) {
+	// the path may hold colons itself
+	i := strings.LastIndexByte(line, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("bad block %q", line)
+	}
+	b := &Block{Path: line[:i]}
+	_, err := fmt.Sscanf(line[i+1:], "%d.%d,%d.%d %s %d", &b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.Kind, &b.Count)
+	if err != nil {
+		return nil, fmt.Errorf("bad block %q", line)
+	}
+	switch b.Kind {
+	case KindStatement, KindThen, KindElse:
+	default:
+		return nil, fmt.Errorf("unknown kind of block %q", b.Kind)
+	}
+	return b, nil
+}
+
+// A Summary counts the blocks of a file and those that ran.
+type Summary struct {
+	Path                string
+	Statements, Covered int
+	Branches, Taken     int
+}
+
+// Summarize returns a summary of blocks for each file, in order of path.
+func Summarize(blocks []*Block) []*Summary {
+	var summaries []*Summary
+	byPath := map[string]*Summary{}
+	for _, b := range blocks {
+		s, ok := byPath[b.Path]
+		if !ok {
+			s = &Summary{Path: b.Path}
+			byPath[b.Path] = s
+			summaries = append(summaries, s)
+		}
+		if b.Kind == KindStatement {
+			s.Statements++
+			if b.Count > 0 {
+				s.Covered++
+			}
+		} else {
+			s.Branches++
+			if b.Count > 0 {
+				s.Taken++
+			}
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Path < summaries[j].Path })
+	return summaries
+}
+
+// Percent formats the share of n that covered is.
+func Percent(covered, n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(covered)/float64(n))
+}
@@ -0,0 +1,118 @@
+package cover
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+const script = `let sign = fn(n) {
+    if (n < 0) {
+        return -1;
+    }
+    if (n == 0) { 0 } else { 1 }
+};
+let unused = fn() { puts(1) };
+sign(5) + sign(-3)
+`
+
+func run(t *testing.T) []*Block {
+	t.Helper()
+	p := parser.New(lexer.New(script))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	c := New("sign.monkey", program)
+	result := c.Run(program, object.NewEnviroment())
+	if i, ok := result.(*object.Integer); !ok || i.Value != 0 {
+		t.Fatalf("expected 0, got %v", result)
+	}
+	return c.Blocks()
+}
+
+func TestCoverage(t *testing.T) {
+	var got []string
+	for _, b := range run(t) {
+		got = append(got, fmt.Sprintf("%d.%d,%d.%d %s %d", b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.Kind, b.Count))
+	}
+	expected := []string{
+		"1.1,6.2 stmt 1",
+		"2.5,4.6 stmt 2",
+		"2.5,2.7 else 1",
+		"2.16,4.6 then 1",
+		"3.9,3.18 stmt 1",
+		"5.5,5.33 stmt 1",
+		"5.17,5.22 then 0",
+		"5.19,5.20 stmt 0",
+		"5.28,5.33 else 1",
+		"5.30,5.31 stmt 1",
+		"7.1,7.30 stmt 1",
+		"7.21,7.28 stmt 0",
+		"8.1,8.19 stmt 1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected blocks\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	summary := Summarize(run(t))
+	if len(summary) != 1 || *summary[0] != (Summary{Path: "sign.monkey", Statements: 9, Covered: 7, Branches: 4, Taken: 3}) {
+		t.Fatalf("unexpected summary %+v", summary[0])
+	}
+}
+
+func TestProfile(t *testing.T) {
+	blocks := run(t)
+	var out strings.Builder
+	if err := WriteProfile(&out, blocks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "mode: count\nsign.monkey:1.1,6.2 stmt 1\n") {
+		t.Fatalf("unexpected profile\n%s", out.String())
+	}
+	// a second run of the same script adds up
+	read, err := ReadProfile(strings.NewReader(out.String() + out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(blocks) {
+		t.Fatalf("expected %d blocks, got %d", len(blocks), len(read))
+	}
+	for i := range read {
+		if read[i].Count != 2*blocks[i].Count {
+			t.Errorf("block %d: expected %d, got %d", i, 2*blocks[i].Count, read[i].Count)
+		}
+	}
+
+	for _, bad := range []string{"", "mode: set\n", "mode: count\nsign.monkey:1.1,2.2 stmt\n", "mode: count\nsign.monkey:1.1,2.2 loop 1\n"} {
+		if _, err := ReadProfile(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var out strings.Builder
+	read := func(path string) ([]byte, error) {
+		return []byte(script), nil
+	}
+	if err := WriteHTML(&out, run(t), read); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+	for _, s := range []string{
+		"<h2>sign.monkey</h2>",
+		"77.8% (7/9) of statements, 75.0% (3/4) of branches",
+		`<span class="hit" title="ran 2 times"> (n &lt; 0) </span>`,
+		`<span class="miss" title="condition true never">{ </span><span class="miss" title="ran never">0</span>`,
+		`<span class="miss" title="ran never">puts(1)</span>`,
+	} {
+		if !strings.Contains(page, s) {
+			t.Errorf("expected the page to contain %q", s)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var page = template.Must(template.New("cover").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Monkey coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+.summary { color: #555; }
+pre { background: #fafafa; border: 1px solid #ddd; padding: 0.5em 0; line-height: 1.4; }
+.number { display: inline-block; width: 4em; padding-right: 1em; text-align: right; color: #999; user-select: none; }
+.hit { background: #c9f0c9; }
+.miss { background: #f8c8c8; }
+</style>
+</head>
+<body>
+<h1>Monkey coverage</h1>
+<p><span class="hit">covered</span> <span class="miss">not covered</span>; hover over the code for the counts.</p>
+{{range .}}<h2>{{.Path}}</h2>
+<p class="summary">{{.Statements}} of statements, {{.Branches}} of branches</p>
+{{if .Err}}<p>{{.Err}}</p>
+{{else}}<pre>{{range $i, $line := .Lines}}<span class="number">{{inc $i}}</span>{{$line}}
+{{end}}</pre>
+{{end}}{{end}}</body>
+</html>
+`))
+
+type htmlFile struct {
+	Path       string
+	Statements string
+	Branches   string
+	Err        error
+	Lines      []template.HTML
+}
+
+// WriteHTML writes a page that shows the source of each file in blocks,
+// which read returns, with the code that ran and the code that didn't
+// in different colors.
+func WriteHTML(w io.Writer, blocks []*Block, read func(path string) ([]byte, error)) error {
+	var files []*htmlFile
+	for _, s := range Summarize(blocks) {
+		f := &htmlFile{
+			Path:       s.Path,
+			Statements: fmt.Sprintf("%s (%d/%d)", Percent(s.Covered, s.Statements), s.Covered, s.Statements),
+			Branches:   fmt.Sprintf("%s (%d/%d)", Percent(s.Taken, s.Branches), s.Taken, s.Branches),
+		}
+		src, err := read(s.Path)
+		if err != nil {
+			f.Err = err
+		} else {
+			f.Lines = render(string(src), s.Path, blocks)
+		}
+		files = append(files, f)
+	}
+	return page.Execute(w, files)
+}
+
+// render returns the lines of src as HTML, the characters of each block
+// of path in a span. Inner blocks win over the ones around them.
+func render(src, path string, blocks []*Block) []template.HTML {
+	var lines [][]rune
+	for _, line := range strings.Split(strings.TrimSuffix(src, "\n"), "\n") {
+		lines = append(lines, []rune(line))
+	}
+	// covering[line][column] is the innermost block there
+	covering := make([][]*Block, len(lines))
+	for i, line := range lines {
+		covering[i] = make([]*Block, len(line))
+	}
+	for _, b := range blocks {
+		if b.Path != path {
+			continue
+		}
+		for line := b.StartLine; line <= b.EndLine && line <= len(lines); line++ {
+			from, to := 1, len(lines[line-1])+1
+			if line == b.StartLine {
+				from = b.StartCol
+			}
+			if line == b.EndLine {
+				to = min(b.EndCol, to)
+			}
+			for col := max(from, 1); col < to; col++ {
+				covering[line-1][col-1] = b
+			}
+		}
+	}
+
+	out := make([]template.HTML, len(lines))
+	for i, line := range lines {
+		var html strings.Builder
+		for col := 0; col < len(line); {
+			b := covering[i][col]
+			end := col
+			for end < len(line) && covering[i][end] == b {
+				end++
+			}
+			text := template.HTMLEscapeString(string(line[col:end]))
+			if b == nil {
+				html.WriteString(text)
+			} else {
+				class := "hit"
+				if b.Count == 0 {
+					class = "miss"
+				}
+				fmt.Fprintf(&html, `<span class="%s" title="%s">%s</span>`, class, describe(b), text)
+			}
+			col = end
+		}
+		out[i] = template.HTML(html.String())
+	}
+	return out
+}
+
+// describe says how often b ran, for the tooltip.
+func describe(b *Block) string {
+	times := "never"
+	switch b.Count {
+	case 0:
+	case 1:
+		times = "once"
+	default:
+		times = fmt.Sprintf("%d times", b.Count)
+	}
+	switch b.Kind {
+	case KindThen:
+		return "condition true " + times
+	case KindElse:
+		return "condition false " + times
+	}
+	return "ran " + times
+}
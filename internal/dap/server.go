@@ -0,0 +1,515 @@
+// Package dap implements a Debug Adapter Protocol server, so editors can
+// debug Monkey programs with the debugger package. A session debugs one
+// program, given by the launch request.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/debugger"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/resolver"
+)
+
+// Monkey programs have a single thread, which is reported with this ID.
+const threadID = 1
+
+var errNotStopped = errors.New("the program is not stopped")
+
+type Server struct {
+	// mu guards writing to out and seq, the number of the last message
+	mu  sync.Mutex
+	out io.Writer
+	seq int
+
+	path     string
+	program  *ast.Program
+	noDebug  bool
+	d        *debugger.Debugger
+	started  bool
+	finished chan struct{}
+
+	// state guards paused and aborting. While the program is paused its
+	// goroutine runs the functions sent on work until a Resume comes on
+	// resume; nothing else may touch the debugger's frames.
+	state    sync.Mutex
+	paused   bool
+	aborting bool
+	work     chan func()
+	resume   chan debugger.Resume
+
+	// refs holds what variablesReference numbers stand for, an
+	// enviroment or an object, numbered from 1. They are only good until
+	// the program goes on, and only used on its goroutine.
+	refs []interface{}
+}
+
+func NewServer() *Server {
+	s := &Server{
+		finished: make(chan struct{}),
+		work:     make(chan func()),
+		resume:   make(chan debugger.Resume),
+	}
+	s.d = debugger.New(s.stopped)
+	return s
+}
+
+// Serve reads requests from r and writes responses and events to w until
+// the client disconnects or r ends. A program still running then is ended.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	in := bufio.NewReader(r)
+	defer s.stop()
+	for {
+		body, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("dap: bad message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if done := s.handle(&req); done {
+			return nil
+		}
+	}
+}
+
+func (s *Server) send(msg interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	switch m := msg.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+	// a client that went away shows up as the end of the input
+	writeMessage(s.out, msg)
+}
+
+func (s *Server) respond(req *request, body interface{}, err error) {
+	r := &response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+	if err != nil {
+		r.Message = err.Error()
+	}
+	s.send(r)
+}
+
+func (s *Server) event(name string, body interface{}) {
+	s.send(&event{Type: "event", Event: name, Body: body})
+}
+
+// handle answers one request. It returns true once the session is over.
+func (s *Server) handle(req *request) bool {
+	var body interface{}
+	var err error
+	switch req.Command {
+	case "initialize":
+		s.respond(req, &Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		}, nil)
+		s.event("initialized", nil)
+		return false
+	case "launch":
+		err = s.launch(req.Arguments)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req.Arguments)
+	case "configurationDone":
+		s.respond(req, nil, s.start())
+		return false
+	case "threads":
+		body = map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		body, err = s.whileStopped(s.stackTrace)
+	case "scopes":
+		var args ScopesArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			body, err = s.whileStopped(func() (interface{}, error) { return s.scopes(args.FrameID) })
+		}
+	case "variables":
+		var args VariablesArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			body, err = s.whileStopped(func() (interface{}, error) { return s.variables(args.VariablesReference) })
+		}
+	case "evaluate":
+		var args EvaluateArguments
+		if err = json.Unmarshal(req.Arguments, &args); err == nil {
+			body, err = s.whileStopped(func() (interface{}, error) { return s.evaluate(args) })
+		}
+	case "continue", "next", "stepIn", "stepOut":
+		resumes := map[string]debugger.Resume{
+			"continue": debugger.Continue,
+			"next":     debugger.StepOver,
+			"stepIn":   debugger.StepIn,
+			"stepOut":  debugger.StepOut,
+		}
+		if req.Command == "continue" {
+			body = map[string]interface{}{"allThreadsContinued": true}
+		}
+		if !s.isPaused() {
+			s.respond(req, nil, errNotStopped)
+			return false
+		}
+		// answer first, so the client hears of the next stop after
+		s.respond(req, body, nil)
+		s.proceed(resumes[req.Command])
+		return false
+	case "pause":
+		s.d.Pause()
+	case "disconnect", "terminate":
+		s.stop()
+		s.respond(req, nil, nil)
+		return req.Command == "disconnect"
+	default:
+		err = fmt.Errorf("%s is not supported", req.Command)
+	}
+	s.respond(req, body, err)
+	return false
+}
+
+// launch parses the program and expands its macros; it runs once the
+// client is done configuring.
+func (s *Server) launch(raw json.RawMessage) error {
+	var args LaunchArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return err
+	}
+	if s.program != nil {
+		return errors.New("a program has been launched already")
+	}
+	src, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	l := lexer.New(string(src))
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s:%s", args.Program, strings.TrimSpace(p.Errors()[0]))
+	}
+	macroEnv := object.NewEnviroment()
+	evaluator.DefineMacros(program, macroEnv)
+	program, err = evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		return fmt.Errorf("%s:%s", args.Program, err)
+	}
+	if errs := resolver.Resolve(program, nil); len(errs) != 0 {
+		return fmt.Errorf("%s:%s", args.Program, errs[0])
+	}
+	s.path, s.program, s.noDebug = args.Program, program, args.NoDebug
+	s.d.StopOnEntry(args.StopOnEntry)
+	return nil
+}
+
+func (s *Server) setBreakpoints(raw json.RawMessage) (interface{}, error) {
+	var args SetBreakpointsArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	// a statement has to start on a line for it to be stopped at
+	lines := make(map[int]bool)
+	if s.program != nil {
+		ast.Inspect(s.program, func(n ast.Node) bool {
+			if stmt, ok := n.(ast.Statement); ok {
+				if start := ast.Start(stmt); start != nil {
+					lines[start.Line] = true
+				}
+			}
+			return true
+		})
+	}
+	s.d.ClearBreakpoints()
+	breakpoints := []Breakpoint{}
+	for _, b := range args.Breakpoints {
+		bp := Breakpoint{Verified: true, Line: b.Line}
+		if s.program != nil && !lines[b.Line] {
+			bp.Verified = false
+			bp.Message = "no statement starts on this line"
+		}
+		s.d.SetBreakpoint(b.Line)
+		breakpoints = append(breakpoints, bp)
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// start runs the launched program on a goroutine of its own.
+func (s *Server) start() error {
+	if s.program == nil {
+		return errors.New("no program has been launched")
+	}
+	if s.started {
+		return errors.New("the program has been started already")
+	}
+	s.started = true
+	go s.run()
+	return nil
+}
+
+func (s *Server) run() {
+	defer close(s.finished)
+	previous := evaluator.SetOutput(&outputWriter{s: s, category: "stdout"})
+	defer evaluator.SetOutput(previous)
+
+	var result object.Object
+	var err error
+	env := object.NewEnviroment()
+	if s.noDebug {
+		result, err = s.runNoDebug(env)
+	} else {
+		result, err = s.d.Run(s.program, env)
+	}
+	exitCode := 0
+	if e, ok := result.(*object.Error); ok {
+		msg := fmt.Sprintf("%s: %s\n", s.path, e.Message)
+		if e.Line > 0 {
+			msg = fmt.Sprintf("%s:%d:%d: %s\n", s.path, e.Line, e.Column, e.Message)
+		}
+		s.event("output", &OutputEvent{Category: "stderr", Output: msg})
+		exitCode = 1
+	} else if err != nil {
+		exitCode = 1
+	} else if result != nil {
+		s.event("output", &OutputEvent{Category: "stdout", Output: result.Inspect() + "\n"})
+	}
+	s.event("exited", map[string]int{"exitCode": exitCode})
+	s.event("terminated", nil)
+}
+
+// runNoDebug runs the program without the debugger, with only a hook
+// that ends it once stop has been called, like the debugger does.
+func (s *Server) runNoDebug(env *object.Enviroment) (result object.Object, err error) {
+	previous := evaluator.SetHook(abortHook{s})
+	defer func() {
+		evaluator.SetHook(previous)
+		if r := recover(); r != nil {
+			if r != debugger.ErrAborted {
+				panic(r)
+			}
+			result, err = nil, debugger.ErrAborted
+		}
+	}()
+	return evaluator.Eval(s.program, env), nil
+}
+
+// abortHook is the evaluator's hook for a program run without debugging.
+// It checks before each statement whether the program is to end.
+type abortHook struct{ s *Server }
+
+func (h abortHook) Statement(stmt ast.Statement, env *object.Enviroment) {
+	h.s.state.Lock()
+	aborting := h.s.aborting
+	h.s.state.Unlock()
+	if aborting {
+		panic(debugger.ErrAborted)
+	}
+}
+
+func (h abortHook) Call(call *ast.Call, fn object.Object)       {}
+func (h abortHook) Return(call *ast.Call, result object.Object) {}
+
+// stopped is the debugger's onStop: it tells the client and waits for it.
+func (s *Server) stopped(d *debugger.Debugger, stop debugger.Stop) debugger.Resume {
+	s.state.Lock()
+	if s.aborting {
+		s.state.Unlock()
+		return debugger.Abort
+	}
+	s.paused = true
+	s.state.Unlock()
+
+	s.refs = nil
+	s.event("stopped", &StoppedEvent{Reason: stop.Reason, ThreadID: threadID, AllThreadsStopped: true})
+	for {
+		select {
+		case f := <-s.work:
+			f()
+		case r := <-s.resume:
+			return r
+		}
+	}
+}
+
+func (s *Server) isPaused() bool {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.paused
+}
+
+// proceed lets the paused program go on as r says.
+func (s *Server) proceed(r debugger.Resume) {
+	s.state.Lock()
+	s.paused = false
+	s.state.Unlock()
+	s.resume <- r
+}
+
+// whileStopped runs f on the program's goroutine, which has to be paused.
+func (s *Server) whileStopped(f func() (interface{}, error)) (interface{}, error) {
+	if !s.isPaused() {
+		return nil, errNotStopped
+	}
+	var body interface{}
+	var err error
+	done := make(chan struct{})
+	s.work <- func() {
+		body, err = f()
+		close(done)
+	}
+	<-done
+	return body, err
+}
+
+// stop ends the program if it is running and waits for it to finish.
+func (s *Server) stop() {
+	if !s.started {
+		return
+	}
+	s.state.Lock()
+	s.aborting = true
+	paused := s.paused
+	s.paused = false
+	s.state.Unlock()
+	// without the debugger, abortHook sees aborting at the next statement
+	if paused {
+		s.resume <- debugger.Abort
+	} else if !s.noDebug {
+		s.d.Pause()
+	}
+	<-s.finished
+}
+
+func (s *Server) stackTrace() (interface{}, error) {
+	frames := []StackFrame{}
+	for i, f := range s.d.Frames() {
+		frames = append(frames, StackFrame{
+			ID:     i,
+			Name:   f.Name,
+			Source: &Source{Path: s.path},
+			Line:   f.Line,
+			Column: f.Column,
+		})
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+func (s *Server) frame(id int) (*debugger.Frame, error) {
+	frames := s.d.Frames()
+	if id < 0 || id >= len(frames) {
+		return nil, fmt.Errorf("no frame %d", id)
+	}
+	return frames[id], nil
+}
+
+// ref returns a variablesReference number for v.
+func (s *Server) ref(v interface{}) int {
+	s.refs = append(s.refs, v)
+	return len(s.refs)
+}
+
+// scopes lists the enviroments of a frame, the innermost first.
+func (s *Server) scopes(frameID int) (interface{}, error) {
+	f, err := s.frame(frameID)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []Scope{}
+	for env := f.Env; env != nil; env = env.Outer() {
+		name := "Closure"
+		switch {
+		case env.Outer() == nil:
+			name = "Globals"
+		case env == f.Env:
+			name = "Locals"
+		}
+		scopes = append(scopes, Scope{Name: name, VariablesReference: s.ref(env)})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+// variables lists the bindings of an enviroment, or those a function
+// closes over.
+func (s *Server) variables(ref int) (interface{}, error) {
+	if ref < 1 || ref > len(s.refs) {
+		return nil, fmt.Errorf("no variables %d", ref)
+	}
+	var env *object.Enviroment
+	switch v := s.refs[ref-1].(type) {
+	case *object.Enviroment:
+		env = v
+	case *object.Function:
+		env = v.Env
+	case *object.Macro:
+		env = v.Env
+	}
+	variables := []Variable{}
+	if env != nil {
+		for _, name := range env.LocalNames() {
+			obj, _ := env.Get(name)
+			variables = append(variables, s.variable(name, obj))
+		}
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+// variable describes obj. Functions can be expanded into the bindings
+// they close over.
+func (s *Server) variable(name string, obj object.Object) Variable {
+	v := Variable{Name: name, Value: debugger.Summary(obj), Type: string(obj.Type())}
+	switch obj.(type) {
+	case *object.Function, *object.Macro:
+		v.VariablesReference = s.ref(obj)
+	}
+	return v
+}
+
+func (s *Server) evaluate(args EvaluateArguments) (interface{}, error) {
+	frame := 0
+	if args.FrameID != nil {
+		frame = *args.FrameID
+	}
+	obj, err := s.d.Eval(args.Expression, frame)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := obj.(*object.Error); ok {
+		return nil, errors.New(e.Message)
+	}
+	if obj == nil {
+		return map[string]interface{}{"result": "", "variablesReference": 0}, nil
+	}
+	v := s.variable("", obj)
+	return map[string]interface{}{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+}
+
+// outputWriter turns what the program prints into output events.
+type outputWriter struct {
+	s        *Server
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.s.event("output", &OutputEvent{Category: w.category, Output: string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,319 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// message is anything the server sends, decoded loosely.
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Event      string          `json:"event"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a scripted debugger front end talking to a Server over TCP.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	in   *bufio.Reader
+	seq  int
+	// events received while waiting for a response
+	events []*message
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		ln.Close()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		done <- NewServer().Serve(conn, conn)
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, in: bufio.NewReader(conn), done: done}
+}
+
+func (c *client) read() *message {
+	c.t.Helper()
+	body, err := readMessage(c.in)
+	if err != nil {
+		c.t.Fatalf("reading from the server: %s", err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatalf("bad message %s: %s", body, err)
+	}
+	return &msg
+}
+
+// request sends a request and waits for its response, decoding the body
+// into body. Events that come first are kept for expect.
+func (c *client) request(command string, args, body interface{}) *message {
+	c.t.Helper()
+	c.seq++
+	data, _ := json.Marshal(args)
+	if err := writeMessage(c.conn, &request{Seq: c.seq, Type: "request", Command: command, Arguments: data}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq || msg.Command != command {
+			c.t.Fatalf("%s: got the response to %s (%d)", command, msg.Command, msg.RequestSeq)
+		}
+		if msg.Success && body != nil {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatalf("%s: decoding %s: %s", command, msg.Body, err)
+			}
+		}
+		return msg
+	}
+}
+
+// expect waits for event name and decodes its body into body.
+func (c *client) expect(name string, body interface{}) {
+	c.t.Helper()
+	for {
+		var msg *message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.read()
+		}
+		if msg.Type != "event" {
+			c.t.Fatalf("waiting for %s, got a response to %s", name, msg.Command)
+		}
+		if msg.Event != name {
+			c.t.Fatalf("waiting for %s, got %s: %s", name, msg.Event, msg.Body)
+		}
+		if body != nil {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return
+	}
+}
+
+// stoppedAt waits for the program to stop and returns the top frame.
+func (c *client) stoppedAt(reason string) StackFrame {
+	c.t.Helper()
+	var stopped StoppedEvent
+	c.expect("stopped", &stopped)
+	if stopped.Reason != reason || stopped.ThreadID != threadID {
+		c.t.Fatalf("expected a stop for %s, got %+v", reason, stopped)
+	}
+	var trace struct{ StackFrames []StackFrame }
+	c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	return trace.StackFrames[0]
+}
+
+const script = `let add = fn(a, b) {
+    let sum = a + b;
+    sum
+};
+let x = 1;
+let y = add(x, 2);
+puts(y);
+add(y, 3)
+`
+
+func TestSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "add.monkey")
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+
+	var caps Capabilities
+	if msg := c.request("initialize", map[string]string{"adapterID": "monkey"}, &caps); !msg.Success || !caps.SupportsConfigurationDoneRequest {
+		t.Fatalf("initialize failed: %+v %+v", msg, caps)
+	}
+	c.expect("initialized", nil)
+	if msg := c.request("launch", LaunchArguments{Program: path}, nil); !msg.Success {
+		t.Fatalf("launch failed: %s", msg.Message)
+	}
+	var bps struct{ Breakpoints []Breakpoint }
+	c.request("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: path},
+		Breakpoints: []SourceBreakpoint{{Line: 2}, {Line: 4}},
+	}, &bps)
+	if expected := []Breakpoint{{Verified: true, Line: 2}, {Line: 4, Message: "no statement starts on this line"}}; !reflect.DeepEqual(bps.Breakpoints, expected) {
+		t.Fatalf("expected breakpoints %+v, got %+v", expected, bps.Breakpoints)
+	}
+	if msg := c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, nil); msg.Success {
+		t.Fatalf("expected stackTrace to fail before the program stops")
+	}
+	c.request("configurationDone", nil, nil)
+
+	// the first call to add
+	top := c.stoppedAt("breakpoint")
+	if top.Name != "add" || top.Line != 2 || top.Source.Path != path {
+		t.Fatalf("unexpected top frame %+v", top)
+	}
+	var threads struct{ Threads []Thread }
+	c.request("threads", nil, &threads)
+	if len(threads.Threads) != 1 {
+		t.Fatalf("expected one thread, got %+v", threads)
+	}
+	var trace struct{ StackFrames []StackFrame }
+	c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	if len(trace.StackFrames) != 2 || trace.StackFrames[1].Name != "main" || trace.StackFrames[1].Line != 6 {
+		t.Fatalf("unexpected stack %+v", trace.StackFrames)
+	}
+
+	var scopes struct{ Scopes []Scope }
+	c.request("scopes", ScopesArguments{FrameID: 0}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("unexpected scopes %+v", scopes.Scopes)
+	}
+	var locals, globals, closure struct{ Variables []Variable }
+	c.request("variables", VariablesArguments{VariablesReference: scopes.Scopes[0].VariablesReference}, &locals)
+	if expected := []Variable{{Name: "a", Value: "1", Type: "INTIGER_TYPE"}, {Name: "b", Value: "2", Type: "INTIGER_TYPE"}}; !reflect.DeepEqual(locals.Variables, expected) {
+		t.Fatalf("expected locals %+v, got %+v", expected, locals.Variables)
+	}
+	c.request("variables", VariablesArguments{VariablesReference: scopes.Scopes[1].VariablesReference}, &globals)
+	add := globals.Variables[0]
+	if add.Name != "add" || add.Value != "fn(a, b)" || add.VariablesReference == 0 {
+		t.Fatalf("expected add to be expandable, got %+v", globals.Variables)
+	}
+	// add closes over the globals, itself included
+	c.request("variables", VariablesArguments{VariablesReference: add.VariablesReference}, &closure)
+	if len(closure.Variables) != 2 || closure.Variables[0].Name != "add" || closure.Variables[1].Name != "x" {
+		t.Fatalf("unexpected closure %+v", closure.Variables)
+	}
+
+	var result struct{ Result string }
+	frame := 1
+	c.request("evaluate", EvaluateArguments{Expression: "a * 10 + b"}, &result)
+	if result.Result != "12" {
+		t.Fatalf("expected 12, got %q", result.Result)
+	}
+	if msg := c.request("evaluate", EvaluateArguments{Expression: "a", FrameID: &frame}, nil); msg.Success || msg.Message != "identifier not found a" {
+		t.Fatalf("expected a to be unknown in main, got %+v", msg)
+	}
+
+	c.request("next", nil, nil)
+	if top := c.stoppedAt("step"); top.Line != 3 {
+		t.Fatalf("expected next to stop on line 3, got %+v", top)
+	}
+	c.request("stepOut", nil, nil)
+	if top := c.stoppedAt("step"); top.Line != 7 || top.Name != "main" {
+		t.Fatalf("expected stepOut to stop on line 7, got %+v", top)
+	}
+	c.request("continue", nil, nil)
+	var output OutputEvent
+	c.expect("output", &output)
+	if output.Output != "3\n" {
+		t.Fatalf("expected puts to print 3, got %+v", output)
+	}
+	// the second call to add
+	if top := c.stoppedAt("breakpoint"); top.Line != 2 {
+		t.Fatalf("expected to stop in add again, got %+v", top)
+	}
+	c.request("stepIn", nil, nil)
+	c.stoppedAt("step")
+	c.request("continue", nil, nil)
+	c.expect("output", &output)
+	if output.Output != "6\n" {
+		t.Fatalf("expected the program to end with 6, got %+v", output)
+	}
+	var exited struct{ ExitCode int }
+	c.expect("exited", &exited)
+	c.expect("terminated", nil)
+	if msg := c.request("disconnect", nil, nil); !msg.Success {
+		t.Fatalf("disconnect failed: %s", msg.Message)
+	}
+	if err := <-c.done; err != nil {
+		t.Fatalf("expected the server to finish cleanly, got %s", err)
+	}
+}
+
+func TestDisconnectWhileStopped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "add.monkey")
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", nil, nil)
+	c.expect("initialized", nil)
+	c.request("launch", LaunchArguments{Program: path, StopOnEntry: true}, nil)
+	c.request("configurationDone", nil, nil)
+	if top := c.stoppedAt("entry"); top.Line != 1 {
+		t.Fatalf("expected to stop on entry, got %+v", top)
+	}
+	c.request("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("expected the server to finish cleanly, got %s", err)
+	}
+}
+
+func TestDisconnectWhileRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fib.monkey")
+	src := `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+puts(0);
+fib(100)
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, noDebug := range []bool{true, false} {
+		c := newClient(t)
+		// a program that isn't ended fails the test rather than hanging it
+		c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+		c.request("initialize", nil, nil)
+		c.expect("initialized", nil)
+		if msg := c.request("launch", LaunchArguments{Program: path, NoDebug: noDebug}, nil); !msg.Success {
+			t.Fatalf("launch failed: %s", msg.Message)
+		}
+		c.request("configurationDone", nil, nil)
+		c.expect("output", nil)
+		if msg := c.request("disconnect", nil, nil); !msg.Success {
+			t.Fatalf("noDebug %v: disconnect failed: %s", noDebug, msg.Message)
+		}
+		if err := <-c.done; err != nil {
+			t.Fatalf("noDebug %v: expected the server to finish cleanly, got %s", noDebug, err)
+		}
+	}
+}
+
+func TestLaunchSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.monkey")
+	if err := os.WriteFile(path, []byte("let x = ;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", nil, nil)
+	msg := c.request("launch", LaunchArguments{Program: path}, nil)
+	if msg.Success || msg.Message != path+":1:9: no prefix func for ;" {
+		t.Fatalf("expected the syntax error, got %+v", msg)
+	}
+}
@@ -0,0 +1,271 @@
+// Package debugger runs Monkey programs under control: it stops at
+// breakpoints and steps, and lets a front end look at the call stack and
+// the bindings while the program is stopped. The front end decides what
+// to do at every stop; `monkey debug` asks the user on the terminal.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+// ErrAborted is returned by Run when the front end ended the program.
+var ErrAborted = errors.New("debugger: program aborted")
+
+// Resume says how the program goes on after a stop.
+type Resume int
+
+const (
+	// Continue runs to the next breakpoint.
+	Continue Resume = iota
+	// StepIn stops at the next statement, inside a call if there is one.
+	StepIn
+	// StepOver stops at the next statement of the current function or of
+	// one of its callers.
+	StepOver
+	// StepOut stops at the next statement after the current function has
+	// returned.
+	StepOut
+	// Abort ends the program.
+	Abort
+)
+
+// Why the program stopped.
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// A Stop tells the front end where and why the program stopped.
+type Stop struct {
+	Reason string
+	Line   int
+	Column int
+}
+
+// A Frame is a function call in progress, or the top level of the program.
+type Frame struct {
+	// Name is the function called as the call shows it, like add or
+	// newAdder(2), or "main" for the top level
+	Name string
+	// Env holds the frame's bindings, nil until its first statement runs
+	Env *object.Enviroment
+	// Line and Column give the statement the frame is running
+	Line   int
+	Column int
+}
+
+type Debugger struct {
+	onStop      func(*Debugger, Stop) Resume
+	stopOnEntry bool
+	// mu guards the breakpoints and pause, which front ends may change
+	// from other goroutines while the program runs
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool
+	// frames is the call stack, the innermost frame last
+	frames []*Frame
+	// what to stop at next, set by the last resume
+	resume Resume
+	// the depth and line of the last stop
+	stopDepth int
+	stopLine  int
+	// set while Eval runs code for the front end, which must not stop
+	evaluating bool
+}
+
+// New returns a debugger that calls onStop whenever the program stops.
+// The program waits for onStop to return and goes on as it says.
+func New(onStop func(*Debugger, Stop) Resume) *Debugger {
+	return &Debugger{onStop: onStop, breakpoints: make(map[int]bool)}
+}
+
+// StopOnEntry makes the program stop before its first statement.
+func (d *Debugger) StopOnEntry(stop bool) {
+	d.stopOnEntry = stop
+}
+
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]bool)
+}
+
+// Pause makes the running program stop at its next statement.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pause = true
+}
+
+// Breakpoints returns the lines with a breakpoint, in order.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Frames returns the call stack, the innermost frame first.
+func (d *Debugger) Frames() []*Frame {
+	frames := make([]*Frame, len(d.frames))
+	for i, f := range d.frames {
+		frames[len(d.frames)-1-i] = f
+	}
+	return frames
+}
+
+// Run evaluates program in env under the debugger and returns what it
+// evaluates to. Only one program can be debugged at a time, since the
+// debugger is the evaluator's hook while it runs.
+func (d *Debugger) Run(program *ast.Program, env *object.Enviroment) (result object.Object, err error) {
+	d.frames = []*Frame{{Name: "main", Env: env}}
+	d.stopDepth, d.stopLine = 0, 0
+	d.resume = Continue
+	if d.stopOnEntry {
+		d.resume = StepIn
+	}
+	previous := evaluator.SetHook(d)
+	defer func() {
+		evaluator.SetHook(previous)
+		d.frames = nil
+		if r := recover(); r != nil {
+			if r != ErrAborted {
+				panic(r)
+			}
+			result, err = nil, ErrAborted
+		}
+	}()
+	return evaluator.Eval(program, env), nil
+}
+
+// Eval evaluates src in the frame at index frame of Frames while the
+// program is stopped. Breakpoints don't apply to it.
+func (d *Debugger) Eval(src string, frame int) (object.Object, error) {
+	frames := d.Frames()
+	if frame < 0 || frame >= len(frames) {
+		return nil, fmt.Errorf("no frame %d", frame)
+	}
+	env := frames[frame].Env
+	if env == nil {
+		return nil, fmt.Errorf("frame %d has not started", frame)
+	}
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, errors.New(strings.TrimSpace(p.Errors()[0]))
+	}
+	d.evaluating = true
+	defer func() { d.evaluating = false }()
+	return evaluator.Eval(program, env), nil
+}
+
+// Statement implements evaluator.Hook and decides whether to stop.
+func (d *Debugger) Statement(stmt ast.Statement, env *object.Enviroment) {
+	if d.evaluating {
+		return
+	}
+	start := ast.Start(stmt)
+	if start == nil {
+		return
+	}
+	frame := d.frames[len(d.frames)-1]
+	newLine := frame.Line != start.Line
+	frame.Env = env
+	frame.Line, frame.Column = start.Line, start.Column
+
+	depth := len(d.frames)
+	// steps go by line: other statements on the line of the last stop in
+	// the same frame are run through
+	sameLine := depth == d.stopDepth && start.Line == d.stopLine
+	d.mu.Lock()
+	breakpoint, pause := d.breakpoints[start.Line], d.pause
+	d.pause = false
+	d.mu.Unlock()
+	reason := ""
+	switch {
+	case pause:
+		reason = ReasonPause
+	case d.resume == StepIn && d.stopDepth == 0:
+		reason = ReasonEntry
+	case d.resume == StepIn && !sameLine:
+		reason = ReasonStep
+	case d.resume == StepOver && depth <= d.stopDepth && !sameLine:
+		reason = ReasonStep
+	case d.resume == StepOut && depth < d.stopDepth:
+		reason = ReasonStep
+	case breakpoint && newLine:
+		reason = ReasonBreakpoint
+	}
+	if reason == "" {
+		return
+	}
+	d.stopDepth, d.stopLine = depth, start.Line
+	d.resume = d.onStop(d, Stop{Reason: reason, Line: start.Line, Column: start.Column})
+	if d.resume == Abort {
+		panic(ErrAborted)
+	}
+}
+
+// Call implements evaluator.Hook and pushes a frame for the call.
+func (d *Debugger) Call(call *ast.Call, fn object.Object) {
+	if d.evaluating {
+		return
+	}
+	d.frames = append(d.frames, &Frame{Name: call.Function.String()})
+}
+
+// Return implements evaluator.Hook and pops the frame of the call.
+func (d *Debugger) Return(call *ast.Call, result object.Object) {
+	if d.evaluating {
+		return
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+// Summary describes obj on one line, giving functions and macros by their
+// parameters rather than their whole body.
+func Summary(obj object.Object) string {
+	var keyword string
+	var params []*ast.Identifier
+	switch obj := obj.(type) {
+	case *object.Function:
+		keyword, params = "fn", obj.Params
+	case *object.Macro:
+		keyword, params = "macro", obj.Params
+	default:
+		return obj.Inspect()
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Value
+	}
+	return keyword + "(" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,119 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+const script = `let add = fn(a, b) {
+    let sum = a + b;
+    sum
+};
+let x = 1;
+let y = add(x, 2);
+let z = add(y, 3);
+y * z
+`
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+// run debugs script, answering the stops with resumes in turn, and
+// returns where it stopped as "line:reason" with the call stack.
+func run(t *testing.T, breakpoints []int, entry bool, resumes ...Resume) []string {
+	t.Helper()
+	var stops []string
+	d := New(func(d *Debugger, stop Stop) Resume {
+		var names []string
+		for _, f := range d.Frames() {
+			names = append(names, f.Name)
+		}
+		stops = append(stops, fmt.Sprintf("%d:%s %s", stop.Line, stop.Reason, strings.Join(names, "<")))
+		if len(resumes) == 0 {
+			return Continue
+		}
+		r := resumes[0]
+		resumes = resumes[1:]
+		return r
+	})
+	d.StopOnEntry(entry)
+	for _, line := range breakpoints {
+		d.SetBreakpoint(line)
+	}
+	result, err := d.Run(parse(t, script), object.NewEnviroment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, ok := result.(*object.Integer); !ok || i.Value != 18 {
+		t.Fatalf("expected 18, got %v", result)
+	}
+	return stops
+}
+
+func TestStepping(t *testing.T) {
+	input := []struct {
+		name        string
+		breakpoints []int
+		entry       bool
+		resumes     []Resume
+		expected    []string
+	}{
+		{"no stops", nil, false, nil, nil},
+		{"entry", nil, true, nil, []string{"1:entry main"}},
+		{"breakpoint in a function", []int{2}, false, nil,
+			[]string{"2:breakpoint add<main", "2:breakpoint add<main"}},
+		{"step over", nil, true, []Resume{StepOver, StepOver, StepOver, StepOver},
+			[]string{"1:entry main", "5:step main", "6:step main", "7:step main", "8:step main"}},
+		{"step in", nil, true, []Resume{StepOver, StepOver, StepIn, StepIn, StepIn, StepIn},
+			[]string{"1:entry main", "5:step main", "6:step main", "2:step add<main", "3:step add<main", "7:step main", "2:step add<main"}},
+		{"step out", []int{2}, false, []Resume{StepOut, StepOut},
+			[]string{"2:breakpoint add<main", "7:step main", "2:breakpoint add<main"}},
+		{"breakpoints stop steps", []int{2}, true, []Resume{StepOver, StepOver, StepOver, Continue},
+			[]string{"1:entry main", "5:step main", "6:step main", "2:breakpoint add<main", "2:breakpoint add<main"}},
+	}
+	for _, tt := range input {
+		got := run(t, tt.breakpoints, tt.entry, tt.resumes...)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected stops %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestEvalInFrame(t *testing.T) {
+	var values []string
+	d := New(func(d *Debugger, stop Stop) Resume {
+		for _, src := range []string{"sum * 10", "x", "add(100, 1)"} {
+			for frame := range d.Frames() {
+				obj, err := d.Eval(src, frame)
+				if err != nil {
+					t.Fatal(err)
+				}
+				values = append(values, fmt.Sprintf("%d:%s", frame, obj.Inspect()))
+			}
+		}
+		return Abort
+	})
+	d.SetBreakpoint(3)
+	_, err := d.Run(parse(t, script), object.NewEnviroment())
+	if err != ErrAborted {
+		t.Fatalf("expected the program to be aborted, got %v", err)
+	}
+	expected := []string{"0:30", "1:1:1: identifier not found sum", "0:1", "1:1", "0:101", "1:101"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %q, got %q", expected, values)
+	}
+}
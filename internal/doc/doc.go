@@ -0,0 +1,175 @@
+// Package doc turns the doc comments of Monkey scripts into API
+// documentation.
+//
+// A doc comment is the comments right above a top-level
+// `let name = fn(...)`, with no blank line between them. Each script is a
+// module, named by its path, and a name in backquotes in a doc comment,
+// like `add` or `math.add`, links to the function it names, in the same
+// module or in another one.
+package doc
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/tester"
+)
+
+// A Module is the documentation of one script.
+type Module struct {
+	// Name is the path of the script from the directory documented, with
+	// slashes and without the .monkey extension
+	Name  string
+	Funcs []*Func
+}
+
+// A Func is a function bound at the top level of a module.
+type Func struct {
+	Name   string
+	Params []string
+	Doc    string
+	Line   int
+}
+
+// Signature returns how the function is bound, like let add = fn(a, b).
+func (f *Func) Signature() string {
+	return "let " + f.Name + " = fn(" + strings.Join(f.Params, ", ") + ")"
+}
+
+// Synopsis returns the first line of the function's doc comment.
+func (f *Func) Synopsis() string {
+	line, _, _ := strings.Cut(f.Doc, "\n")
+	return line
+}
+
+// Find returns the scripts in dir and the directories below it, sorted,
+// leaving out tests and hidden directories.
+func Find(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".monkey") && !strings.HasSuffix(path, tester.Suffix) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ModuleName returns the name of the module in the script at file, found
+// in dir.
+func ModuleName(dir, file string) string {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		rel = file
+	}
+	return strings.TrimSuffix(filepath.ToSlash(rel), ".monkey")
+}
+
+// New returns the documentation of program, the module called name. The
+// parser has to have kept the comments. A function bound more than once
+// is documented where it is bound first.
+func New(name string, program *ast.Program) *Module {
+	m := &Module{Name: name}
+	seen := map[string]bool{}
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let.Name == nil || seen[let.Name.Value] {
+			continue
+		}
+		fn, ok := let.Value.(*ast.FunctionLiteral)
+		if !ok {
+			continue
+		}
+		seen[let.Name.Value] = true
+		f := &Func{Name: let.Name.Value, Doc: fn.Doc, Line: let.Name.Token.Line}
+		for _, p := range fn.Params {
+			f.Params = append(f.Params, p.Value)
+		}
+		m.Funcs = append(m.Funcs, f)
+	}
+	return m
+}
+
+func (m *Module) lookup(name string) *Func {
+	for _, f := range m.Funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// base returns the last element of the module's name, which is enough to
+// refer to it as long as no other module has the same one.
+func (m *Module) base() string {
+	return path.Base(m.Name)
+}
+
+// resolve finds the function ref, the text of a code span in the doc
+// comments of from, refers to: a name, maybe followed by its arguments,
+// and maybe qualified by a module. An unqualified name is looked up in
+// from first, then in the one other module that binds it, if there's
+// exactly one.
+func resolve(ref string, from *Module, modules []*Module) (*Module, *Func) {
+	ref, _, _ = strings.Cut(ref, "(")
+	ref = strings.TrimSpace(ref)
+	if i := strings.LastIndex(ref, "."); i >= 0 {
+		qualifier, name := ref[:i], ref[i+1:]
+		var found *Module
+		for _, m := range modules {
+			if m.Name == qualifier {
+				found = m
+				break
+			}
+			if m.base() == qualifier {
+				if found != nil {
+					// ambiguous
+					return nil, nil
+				}
+				found = m
+			}
+		}
+		if found == nil {
+			return nil, nil
+		}
+		if f := found.lookup(name); f != nil {
+			return found, f
+		}
+		return nil, nil
+	}
+	if f := from.lookup(ref); f != nil {
+		return from, f
+	}
+	var module *Module
+	var fn *Func
+	for _, m := range modules {
+		if m == from {
+			continue
+		}
+		if f := m.lookup(ref); f != nil {
+			if fn != nil {
+				return nil, nil
+			}
+			module, fn = m, f
+		}
+	}
+	return module, fn
+}
+
+// relative returns the path to the page of module to, from the page of
+// module from, for a page extension ext. An empty from is the index.
+func relative(from, to, ext string) string {
+	up := strings.Count(from, "/")
+	return strings.Repeat("../", up) + to + ext
+}
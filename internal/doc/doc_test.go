@@ -0,0 +1,162 @@
+package doc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+func module(t *testing.T, name, src string) *Module {
+	t.Helper()
+	l := lexer.New(src)
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: unexpected syntax errors %v", src, p.Errors())
+	}
+	return New(name, program)
+}
+
+func TestNew(t *testing.T) {
+	m := module(t, "math", `// math helpers
+
+// add returns a + b.
+let add = fn(a, b) { a + b };
+let x = 1;
+let neg = fn(n) {
+	// not a doc comment
+	let inner = fn() { 1 };
+	-n
+};
+// the first binding counts
+let add = fn() { 0 };
+`)
+	expected := []*Func{
+		{Name: "add", Params: []string{"a", "b"}, Doc: "add returns a + b.", Line: 4},
+		{Name: "neg", Params: []string{"n"}, Line: 6},
+	}
+	if !reflect.DeepEqual(m.Funcs, expected) {
+		t.Fatalf("expected %+v got %+v", expected, m.Funcs)
+	}
+	if got := m.Funcs[0].Signature(); got != "let add = fn(a, b)" {
+		t.Errorf("unexpected signature %q", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	math := module(t, "lib/math", "let add = fn(a, b) { a + b }; let twice = fn(x) { x };")
+	strs := module(t, "strings", "let twice = fn(s) { s }; let join = fn(a, b) { a };")
+	other := module(t, "other/math", "let sub = fn(a, b) { a - b };")
+	modules := []*Module{math, strs, other}
+	input := []struct {
+		ref      string
+		from     *Module
+		module   *Module
+		function string
+	}{
+		{"add", strs, math, "add"},
+		{"add(1, 2)", strs, math, "add"},
+		{"twice", strs, strs, "twice"},
+		{"twice", other, nil, ""},
+		{"lib/math.twice", strs, math, "twice"},
+		{"strings.twice", math, strs, "twice"},
+		{"math.add", strs, nil, ""},
+		{"nope", math, nil, ""},
+		{"strings.nope", math, nil, ""},
+	}
+	for _, tt := range input {
+		m, f := resolve(tt.ref, tt.from, modules)
+		name := ""
+		if f != nil {
+			name = f.Name
+		}
+		if m != tt.module || name != tt.function {
+			t.Errorf("%q from %s: expected %v %q got %v %q", tt.ref, tt.from.Name, tt.module, tt.function, m, name)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	modules := []*Module{
+		module(t, "lib/math", "// add adds, see `sub` and `strings.twice`.\nlet add = fn(a, b) { a + b };\nlet sub = fn(a, b) { a - b };"),
+		module(t, "strings", "// twice uses `add` <twice>.\nlet twice = fn(s) { s };"),
+	}
+	pages, err := Render(modules, Markdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"index.md": {
+			"- [`add(a, b)`](lib/math.md#add): add adds, see [`sub`](lib/math.md#sub) and [`strings.twice`](strings.md#twice).",
+			"- [`sub(a, b)`](lib/math.md#sub)\n",
+		},
+		"lib/math.md": {
+			"[Index](../index.md)",
+			"<a id=\"add\"></a>\n## add\n\n```monkey\nlet add = fn(a, b)\n```\n\nadd adds, see [`sub`](#sub) and [`strings.twice`](../strings.md#twice).\n",
+		},
+		"strings.md": {"twice uses [`add`](lib/math.md#add) <twice>."},
+	}
+	checkPages(t, pages, expected)
+
+	pages, err = Render(modules, HTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string][]string{
+		"index.html": {
+			`<dt><a href="lib/math.html#sub"><code>sub(a, b)</code></a></dt>`,
+			`<dd><p>twice uses <a href="lib/math.html#add"><code>add</code></a> &lt;twice&gt;.</p>`,
+		},
+		"lib/math.html": {
+			`<a href="../index.html">Index</a>`,
+			`<h2 id="add">add</h2>`,
+			`see <a href="#sub"><code>sub</code></a> and <a href="../strings.html#twice"><code>strings.twice</code></a>.`,
+		},
+		"strings.html": {`<pre>let twice = fn(s)</pre>`},
+	}
+	checkPages(t, pages, expected)
+}
+
+func checkPages(t *testing.T, pages []*Page, expected map[string][]string) {
+	t.Helper()
+	if len(pages) != len(expected) {
+		t.Errorf("expected %d pages got %d", len(expected), len(pages))
+	}
+	for _, page := range pages {
+		for _, want := range expected[page.Path] {
+			if !strings.Contains(string(page.Data), want) {
+				t.Errorf("expected %s to contain %q, got\n%s", page.Path, want, page.Data)
+			}
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.monkey", "a_test.monkey", "lib/b.monkey", ".git/c.monkey", "notes.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, ModuleName(dir, f))
+	}
+	if expected := []string{"a", "lib/b"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %q got %q", expected, names)
+	}
+}
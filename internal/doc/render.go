@@ -0,0 +1,232 @@
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// A Format is a kind of page Render writes.
+type Format int
+
+const (
+	Markdown Format = iota
+	HTML
+)
+
+func (f Format) ext() string {
+	if f == HTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// A Page is one file of the documentation.
+type Page struct {
+	// Path is where the page goes, with slashes, from the directory the
+	// documentation is written to
+	Path string
+	Data []byte
+}
+
+// Render returns the pages documenting modules: an index, and a page per
+// module, in format.
+func Render(modules []*Module, format Format) ([]*Page, error) {
+	r := &renderer{modules: modules, format: format}
+	var pages []*Page
+	index, err := r.index()
+	if err != nil {
+		return nil, err
+	}
+	pages = append(pages, &Page{Path: "index" + format.ext(), Data: index})
+	for _, m := range modules {
+		data, err := r.module(m)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, &Page{Path: m.Name + format.ext(), Data: data})
+	}
+	return pages, nil
+}
+
+type renderer struct {
+	modules []*Module
+	format  Format
+}
+
+// href returns the link to f, in module m, from the page of module from,
+// or the index if from is nil.
+func (r *renderer) href(from, m *Module, f *Func) string {
+	if from == m {
+		return "#" + f.Name
+	}
+	fromName := ""
+	if from != nil {
+		fromName = from.Name
+	}
+	return relative(fromName, m.Name, r.format.ext()) + "#" + f.Name
+}
+
+// codeSpan matches text in backquotes, which may name a function.
+var codeSpan = regexp.MustCompile("`([^`\n]+)`")
+
+func (r *renderer) index() ([]byte, error) {
+	if r.format == HTML {
+		var modules []htmlModule
+		for _, m := range r.modules {
+			hm := htmlModule{Name: m.Name, Href: relative("", m.Name, r.format.ext())}
+			for _, f := range m.Funcs {
+				hm.Funcs = append(hm.Funcs, htmlFunc{
+					Name:      f.Name,
+					Href:      r.href(nil, m, f),
+					Signature: f.Name + "(" + strings.Join(f.Params, ", ") + ")",
+					Doc:       r.html(m, nil, f.Synopsis()),
+				})
+			}
+			modules = append(modules, hm)
+		}
+		var out bytes.Buffer
+		err := indexPage.Execute(&out, modules)
+		return out.Bytes(), err
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# Modules\n")
+	for _, m := range r.modules {
+		fmt.Fprintf(&out, "\n## [%s](%s)\n\n", m.Name, relative("", m.Name, r.format.ext()))
+		if len(m.Funcs) == 0 {
+			fmt.Fprintf(&out, "No functions.\n")
+		}
+		for _, f := range m.Funcs {
+			fmt.Fprintf(&out, "- [`%s(%s)`](%s)", f.Name, strings.Join(f.Params, ", "), r.href(nil, m, f))
+			if s := f.Synopsis(); s != "" {
+				fmt.Fprintf(&out, ": %s", r.markdown(m, nil, s))
+			}
+			fmt.Fprintf(&out, "\n")
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func (r *renderer) module(m *Module) ([]byte, error) {
+	index := relative(m.Name, "index", r.format.ext())
+	if r.format == HTML {
+		page := htmlModule{Name: m.Name, Href: index}
+		for _, f := range m.Funcs {
+			page.Funcs = append(page.Funcs, htmlFunc{
+				Name:      f.Name,
+				Signature: f.Signature(),
+				Doc:       r.html(m, m, f.Doc),
+			})
+		}
+		var out bytes.Buffer
+		err := modulePage.Execute(&out, page)
+		return out.Bytes(), err
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# %s\n\n[Index](%s)\n", m.Name, index)
+	for _, f := range m.Funcs {
+		fmt.Fprintf(&out, "\n<a id=\"%s\"></a>\n## %s\n\n```monkey\n%s\n```\n", f.Name, f.Name, f.Signature())
+		if f.Doc != "" {
+			fmt.Fprintf(&out, "\n%s\n", r.markdown(m, m, f.Doc))
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// markdown returns text, from the doc comments of m, with the code spans
+// that name functions made links to them from the page of module page, or
+// the index if page is nil.
+func (r *renderer) markdown(m, page *Module, text string) string {
+	return codeSpan.ReplaceAllStringFunc(text, func(span string) string {
+		target, f := resolve(strings.Trim(span, "`"), m, r.modules)
+		if f == nil {
+			return span
+		}
+		return "[" + span + "](" + r.href(page, target, f) + ")"
+	})
+}
+
+// html returns text, from the doc comments of m, as HTML paragraphs, with
+// the code spans in code elements and the ones that name functions made
+// links to them from page, like markdown.
+func (r *renderer) html(m, page *Module, text string) template.HTML {
+	var out strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		out.WriteString("<p>")
+		last := 0
+		for _, loc := range codeSpan.FindAllStringSubmatchIndex(para, -1) {
+			out.WriteString(template.HTMLEscapeString(para[last:loc[0]]))
+			code := para[loc[2]:loc[3]]
+			span := "<code>" + template.HTMLEscapeString(code) + "</code>"
+			if target, f := resolve(code, m, r.modules); f != nil {
+				span = `<a href="` + template.HTMLEscapeString(r.href(page, target, f)) + `">` + span + "</a>"
+			}
+			out.WriteString(span)
+			last = loc[1]
+		}
+		out.WriteString(template.HTMLEscapeString(para[last:]))
+		out.WriteString("</p>\n")
+	}
+	return template.HTML(out.String())
+}
+
+type htmlModule struct {
+	Name  string
+	Href  string
+	Funcs []htmlFunc
+}
+
+type htmlFunc struct {
+	Name      string
+	Href      string
+	Signature string
+	Doc       template.HTML
+}
+
+const style = `<style>
+body { font-family: sans-serif; margin: 2em; max-width: 50em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+pre { background: #fafafa; border: 1px solid #ddd; padding: 0.5em; }
+dd { margin-bottom: 0.5em; }
+</style>`
+
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Modules</title>
+` + style + `
+</head>
+<body>
+<h1>Modules</h1>
+{{range .}}<h2><a href="{{.Href}}">{{.Name}}</a></h2>
+{{if .Funcs}}<dl>
+{{range .Funcs}}<dt><a href="{{.Href}}"><code>{{.Signature}}</code></a></dt>
+{{if .Doc}}<dd>{{.Doc}}</dd>
+{{end}}{{end}}</dl>
+{{else}}<p>No functions.</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+var modulePage = template.Must(template.New("module").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+` + style + `
+</head>
+<body>
+<p><a href="{{.Href}}">Index</a></p>
+<h1>{{.Name}}</h1>
+{{range .Funcs}}<h2 id="{{.Name}}">{{.Name}}</h2>
+<pre>{{.Signature}}</pre>
+{{.Doc}}{{end}}</body>
+</html>
+`))
@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+// The assertions test files make. A failed assertion is a runtime error,
+// so it ends the test, and the error says what was expected and what was
+// found by their Inspect output.
+func init() {
+	// registered here rather than in the builtins literal since
+	// assert_error calls back into the evaluator, which looks builtins up
+	for _, b := range []*object.Builtin{
+		{Name: "assert", Fn: assert},
+		{Name: "assert_eq", Fn: assertEq},
+		{Name: "assert_error", Fn: assertError},
+	} {
+		builtins[b.Name] = b
+	}
+}
+
+// assert(cond) fails unless cond is true.
+func assert(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments to assert: want 1 got %d", len(args))
+	}
+	if b, ok := args[0].(*object.Boolean); !ok || !b.Value {
+		return newError("assertion failed: got %s", args[0].Inspect())
+	}
+	return NULL
+}
+
+// assert_eq(actual, expected) fails unless actual equals expected.
+func assertEq(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments to assert_eq: want 2 got %d", len(args))
+	}
+	if !Equal(args[0], args[1]) {
+		return newError("assert_eq failed: expected %s, got %s", args[1].Inspect(), args[0].Inspect())
+	}
+	return NULL
+}
+
+// assert_error(fn) calls fn without arguments and fails unless it ends
+// with a runtime error.
+func assertError(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments to assert_error: want 1 got %d", len(args))
+	}
+	switch args[0].(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return newError("assert_error takes a function, got %s", args[0].Type())
+	}
+	result := applyFunction(args[0], nil)
+	if isError(result) {
+		return NULL
+	}
+	return newError("assert_error failed: expected an error, got %s", result.Inspect())
+}
+
+// Equal reports whether a and b are the same value: integers and
+// booleans by value, quotes by their code and anything else, like
+// functions, only if it is the same object.
+func Equal(a, b object.Object) bool {
+	switch a := a.(type) {
+	case *object.Integer:
+		b, ok := b.(*object.Integer)
+		return ok && a.Value == b.Value
+	case *object.Boolean:
+		b, ok := b.(*object.Boolean)
+		return ok && a.Value == b.Value
+	case *object.Null:
+		_, ok := b.(*object.Null)
+		return ok
+	case *object.Quote:
+		b, ok := b.(*object.Quote)
+		return ok && a.Node.String() == b.Node.String()
+	}
+	return a == b
+}
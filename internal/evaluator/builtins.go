@@ -0,0 +1,74 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+// builtins holds the functions every program can call without defining
+// them, by name. A binding of the same name hides the builtin, and
+// Builtins lists them for completion.
+var builtins = map[string]*object.Builtin{
+	"puts": {Name: "puts", Fn: puts},
+	"help": {Name: "help", Fn: help},
+}
+
+// output is where puts writes.
+var output io.Writer = os.Stdout
+
+// SetOutput makes puts write to w and returns the writer it wrote to
+// before.
+func SetOutput(w io.Writer) io.Writer {
+	previous := output
+	output = w
+	return previous
+}
+
+// Builtins returns the names of the builtin functions, sorted.
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// puts prints its arguments, one per line.
+func puts(args ...object.Object) object.Object {
+	for _, arg := range args {
+		fmt.Fprintln(output, arg.Inspect())
+	}
+	return NULL
+}
+
+// help prints the signature of the function it is given and the doc
+// comment above the let that bound it.
+func help(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments to help: want 1 got %d", len(args))
+	}
+	switch fn := args[0].(type) {
+	case *object.Builtin:
+		fmt.Fprintf(output, "%s is a builtin\n", fn.Name)
+	case *object.Function:
+		params := make([]string, len(fn.Params))
+		for i, p := range fn.Params {
+			params[i] = p.Value
+		}
+		fmt.Fprintf(output, "fn(%s)\n", strings.Join(params, ", "))
+		if fn.Doc != "" {
+			for _, line := range strings.Split(fn.Doc, "\n") {
+				fmt.Fprintln(output, strings.TrimRight("    "+line, " "))
+			}
+		}
+	default:
+		return newError("help takes a function, got %s", args[0].Type())
+	}
+	return NULL
+}
@@ -2,6 +2,8 @@ package evaluator
 
 // testing comment
 import (
+	"bytes"
+	"sort"
 	"testing"
 
 	"github.com/myselfBZ/interpreter/internal/lexer"
@@ -20,7 +22,7 @@ func TestEvaluatorInt(t *testing.T) {
 	l := lexer.New(input.input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	obj := Eval(program)
+	obj := Eval(program, object.NewEnviroment())
 	i, ok := obj.(*object.Integer)
 	if !ok {
 		t.Fatalf("expected int got %T\n", obj.(*object.Integer))
@@ -41,7 +43,7 @@ func TestEvalBoolean(t *testing.T) {
 	l := lexer.New(input.input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	obj := Eval(program)
+	obj := Eval(program, object.NewEnviroment())
 	b, ok := obj.(*object.Boolean)
 	if !ok {
 		t.Fatalf("expected boolean got %T", obj)
@@ -65,7 +67,7 @@ func TestBang(t *testing.T) {
 	if len(p.Errors()) != 0 {
 		t.Fatalf("errors: %s", p.Errors()[0])
 	}
-	v := Eval(program)
+	v := Eval(program, object.NewEnviroment())
 	b, ok := v.(*object.Boolean)
 	if !ok {
 		t.Fatalf("expected boolean object got %T", v.(*object.Boolean))
@@ -74,3 +76,149 @@ func TestBang(t *testing.T) {
 		t.Fatalf("expected %v got %v", input.expct, b.Value)
 	}
 }
+
+func TestPrefixPrecedence(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		// a prefix operator applies to its operand only, not to the rest of
+		// the expression
+		{"-1 + 2", "1"},
+		{"-(1 + 2)", "-3"},
+		{"-2 * 3 + 1", "-5"},
+		{"!true == false", "true"},
+	}
+	for _, tt := range input {
+		if got := Eval(parseProgram(t, tt.input), object.NewEnviroment()).Inspect(); got != tt.expected {
+			t.Errorf("%s: expected %s got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	input := []struct {
+		input    string
+		expected int
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let double = fn(x) { return x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, add(5, 5));", 15},
+		{"let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(3);", 5},
+		{"let five = fn() { 5; }; five();", 5},
+		// annotations are for monkey check and change nothing here
+		{"let add = fn(x: int, y: int) -> int { x + y }; let z: int | null = add(2, 3); z;", 5},
+	}
+	for _, tt := range input {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		obj := Eval(program, object.NewEnviroment())
+		i, ok := obj.(*object.Integer)
+		if !ok {
+			t.Fatalf("%s: expected int got %T (%v)", tt.input, obj, obj)
+		}
+		if i.Value != tt.expected {
+			t.Fatalf("expected %d got %d", tt.expected, i.Value)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	obj := Eval(parseProgram(t, "let x = 1;\nx / (x - 1)"), object.NewEnviroment())
+	if got, expected := obj.Inspect(), "2:3: division by zero"; got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	obj := Eval(parseProgram(t, "puts"), object.NewEnviroment())
+	if _, ok := obj.(*object.Builtin); !ok {
+		t.Fatalf("expected a builtin got %T (%v)", obj, obj)
+	}
+	obj = Eval(parseProgram(t, "let puts = 5; puts"), object.NewEnviroment())
+	if i, ok := obj.(*object.Integer); !ok || i.Value != 5 {
+		t.Fatalf("expected a binding to hide the builtin, got %T (%v)", obj, obj)
+	}
+	if names := Builtins(); !sort.StringsAreSorted(names) || sort.SearchStrings(names, "puts") == len(names) {
+		t.Fatalf("expected puts among the sorted builtins, got %v", names)
+	}
+}
+
+func TestPuts(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"puts(1)", "1\n"},
+		{"puts(1 + 2, true, !true)", "3\ntrue\nfalse\n"},
+		{"puts()", ""},
+		{"let x = 5; puts(x * 2); puts(x)", "10\n5\n"},
+	}
+	for _, tt := range input {
+		var out bytes.Buffer
+		previous := SetOutput(&out)
+		obj := Eval(parseProgram(t, tt.input), object.NewEnviroment())
+		SetOutput(previous)
+		if obj != NULL || out.String() != tt.expected {
+			t.Errorf("%s: expected %q and null, got %q and %v", tt.input, tt.expected, out.String(), obj.Inspect())
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"assert(1 < 2)", "NULL"},
+		{"assert(1 > 2)", "1:7: assertion failed: got false"},
+		{"assert(1)", "1:7: assertion failed: got 1"},
+		{"assert(true == true)", "NULL"},
+		{"assert(true != false)", "NULL"},
+		{"assert(!(true == false))", "NULL"},
+		{"assert(true == false)", "1:7: assertion failed: got false"},
+		{"assert_eq(1 + 2, 3)", "NULL"},
+		{"assert_eq(true, 1 < 2)", "NULL"},
+		{"assert_eq(1 + 2, 4)", "1:10: assert_eq failed: expected 4, got 3"},
+		{"assert_eq(1, true)", "1:10: assert_eq failed: expected true, got 1"},
+		{"let f = fn(x) { x }; assert_eq(f, f)", "NULL"},
+		{"assert_error(fn() { 1 + true })", "NULL"},
+		{"assert_error(fn() { 1 })", "1:13: assert_error failed: expected an error, got 1"},
+		{"assert_error(1)", "1:13: assert_error takes a function, got INTIGER_TYPE"},
+		{"assert_eq(1)", "1:10: wrong number of arguments to assert_eq: want 2 got 1"},
+	}
+	for _, tt := range input {
+		obj := Eval(parseProgram(t, tt.input), object.NewEnviroment())
+		if got := obj.Inspect(); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"// adds a and b\n//\n// or fails\nlet add = fn(a, b) { a + b }; help(add)", "fn(a, b)\n    adds a and b\n\n    or fails\n"},
+		{"help(fn() { 1 })", "fn()\n"},
+		{"help(puts)", "puts is a builtin\n"},
+		{"help(1)", "1:5: help takes a function, got INTIGER_TYPE"},
+	}
+	for _, tt := range input {
+		var out bytes.Buffer
+		previous := SetOutput(&out)
+		l := lexer.New(tt.input)
+		l.EmitComments(true)
+		obj := Eval(parser.New(l).ParseProgram(), object.NewEnviroment())
+		SetOutput(previous)
+		got := out.String()
+		if err, ok := obj.(*object.Error); ok {
+			got = err.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/myselfBZ/interpreter/internal/ast"
 	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/token"
 )
 
 var (
@@ -24,6 +25,16 @@ func newError(format string, a ...interface{}) *object.Error {
     return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// errorAt attaches the position of tok to o if o is an error that doesn't
+// know where it came from yet.
+func errorAt(o object.Object, tok *token.Token) object.Object {
+    if err, ok := o.(*object.Error); ok && err.Line == 0 && tok != nil{
+        err.Line = tok.Line
+        err.Column = tok.Column
+    }
+    return o
+}
+
 func isError(o object.Object) bool{
     if o != nil{
         return o.Type() == object.ERROR_OBJ
@@ -47,16 +58,19 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 		}
 		return FALSE
 	case *ast.PrefixExpression:
-		return evalPrefix(node, node.Operator, env)
+		return errorAt(evalPrefix(node, node.Operator, env), node.Token)
 	case *ast.InfixExperssion:
 		right := Eval(node.Right, env)
         if isError(right){
             return right
         }
 		left := Eval(node.Left, env)
-		return evalInfix(right, left, node.Operator)
+        if isError(left){
+            return left
+        }
+		return errorAt(evalInfix(right, left, node.Operator), node.Token)
     case *ast.IfExpression:
-        return evalIfExp(node, env)
+        return errorAt(evalIfExp(node, env), node.Token)
     case *ast.ReturnStatement:
         value := Eval(node.ReturnValue, env)
         if isError(value){
@@ -68,16 +82,38 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
         if isError(v){
             return v
         }
-        env.Set(node.Name.Value, v)
+        if node.Name.Ref == nil || !env.SetSlot(node.Name.Ref.Slot, v){
+            env.Set(node.Name.Value, v)
+        }
     case *ast.Identifier:
-        v, ok := env.Get(node.Value) 
-        if !ok{
-            return newError("identifier not found %s", node.Value)
+        if node.Ref != nil{
+            if v, ok := env.GetSlot(node.Ref.Depth, node.Ref.Slot); ok{
+                return v
+            }
         }
-        return v
-    // case *ast.FunctionLiteral:
-    //     funcEnv := object.NewEnviroment()
-    //
+        return errorAt(evalIdent(node, env), node.Token)
+    case *ast.FunctionLiteral:
+        return &object.Function{Params: node.Params, Result: node.Result, Body: node.Body, Env: env, Locals: node.Locals, Doc: node.Doc}
+    case *ast.Call:
+        if isQuoteCall(node){
+            return quote(node.Arguments[0], env)
+        }
+        function := Eval(node.Function, env)
+        if isError(function){
+            return function
+        }
+        args := evalExpressions(node.Arguments, env)
+        if len(args) == 1 && isError(args[0]){
+            return args[0]
+        }
+        if hook != nil{
+            hook.Call(node, function)
+        }
+        result := applyFunction(function, args)
+        if hook != nil{
+            hook.Return(node, result)
+        }
+        return errorAt(result, node.Token)
 	default:
 		return NULL
 	}
@@ -87,16 +123,22 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 
 func evalIdent(node *ast.Identifier, env *object.Enviroment) object.Object{
     obj, ok := env.Get(node.Value)
-    if !ok{
-        return newError("identifier not found %s", node.Value)
+    if ok{
+        return obj
     }
-    return obj
+    if builtin, ok := builtins[node.Value]; ok{
+        return builtin
+    }
+    return newError("identifier not found %s", node.Value)
 }
 
 
 func evalProgram(node *ast.Program, env *object.Enviroment) object.Object {
     var result object.Object
     for _, v := range node.Statements{
+        if hook != nil{
+            hook.Statement(v, env)
+        }
         result = Eval(v, env)
         if err, ok := result.(*object.Error); ok{
             return err
@@ -112,6 +154,9 @@ func evalProgram(node *ast.Program, env *object.Enviroment) object.Object {
 func evalBlock(node *ast.BlockStatement, env *object.Enviroment) object.Object {
     var result object.Object
     for _, v := range node.Statements{
+        if hook != nil{
+            hook.Statement(v, env)
+        }
         result = Eval(v, env)
         if result != nil && result.Type() == object.ERROR_OBJ{
             return result
@@ -170,6 +215,9 @@ func evalIntInfix(right object.Object, left object.Object, oprtr string) object.
 	case "*":
 		return &object.Integer{Value: leftValue * rightValue}
 	case "/":
+		if rightValue == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftValue / rightValue}
 	case "==":
 		return boolToBoolOBJ(leftValue == rightValue)
@@ -200,9 +248,9 @@ func compareBool(right object.Object, left object.Object, oprtr string) object.O
 	rightValue := right.(*object.Boolean).Value
 	switch oprtr {
 	case "==":
-		return &object.Boolean{Value: leftValue == rightValue}
+		return boolToBoolOBJ(leftValue == rightValue)
 	case "!=":
-		return &object.Boolean{Value: leftValue != rightValue}
+		return boolToBoolOBJ(leftValue != rightValue)
 	default:
 		return newError("unknown operator between booleans %s", oprtr)
 	}
@@ -220,9 +268,17 @@ func evalBoolInfix(right object.Object, left object.Object, oprtr string) object
 
 func evalIfExp(node *ast.IfExpression, env *object.Enviroment) object.Object {
 	conditionObj := Eval(node.Condition, env)
+    if isError(conditionObj){
+        return conditionObj
+    }
 	condition, ok := conditionObj.(*object.Boolean)
     if !ok{
         return newError("non-boolean condition in if statement %s", conditionObj.Type())
+    }
+    if hook != nil{
+        if b, ok := hook.(BranchHook); ok{
+            b.Branch(node, condition.Value)
+        }
     }
 	if condition.Value {
 		return Eval(node.Consequence, env)
@@ -233,5 +289,52 @@ func evalIfExp(node *ast.IfExpression, env *object.Enviroment) object.Object {
     return NULL
 }
 
+func evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Object {
+    var result []object.Object
+    for _, e := range exps{
+        evaluated := Eval(e, env)
+        if isError(evaluated){
+            return []object.Object{evaluated}
+        }
+        result = append(result, evaluated)
+    }
+    return result
+}
 
+// Apply calls fn, a function or a builtin, with args and returns its
+// result, for tools that call into programs.
+func Apply(fn object.Object, args ...object.Object) object.Object {
+    return applyFunction(fn, args)
+}
 
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+    if builtin, ok := fn.(*object.Builtin); ok{
+        return builtin.Fn(args...)
+    }
+    function, ok := fn.(*object.Function)
+    if !ok{
+        return newError("not a function: %s", fn.Type())
+    }
+    if len(args) != len(function.Params){
+        return newError("wrong number of arguments: want %d got %d", len(function.Params), len(args))
+    }
+    var env *object.Enviroment
+    if function.Locals != nil{
+        env = object.NewFrame(function.Env, function.Locals)
+    } else {
+        env = object.NewEnclosedEnviroment(function.Env)
+    }
+    for i, param := range function.Params{
+        if param.Ref == nil || !env.SetSlot(param.Ref.Slot, args[i]){
+            env.Set(param.Value, args[i])
+        }
+    }
+    evaluated := Eval(function.Body, env)
+    if returnV, ok := evaluated.(*object.ReturnValue); ok{
+        return returnV.Value
+    }
+    if evaluated == nil{
+        return NULL
+    }
+    return evaluated
+}
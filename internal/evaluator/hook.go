@@ -0,0 +1,39 @@
+package evaluator
+
+import (
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+// A Hook is told what the evaluator is doing, for debuggers and other
+// tools that follow a program as it runs. Its methods run on the
+// evaluator's goroutine and the program waits for them to return.
+type Hook interface {
+	// Statement is called before each statement of a program or a block
+	// runs in env. ast.Start(stmt) gives its position.
+	Statement(stmt ast.Statement, env *object.Enviroment)
+	// Call is called when fn is about to be applied for call, after the
+	// arguments have been evaluated, and Return once it has returned.
+	Call(call *ast.Call, fn object.Object)
+	Return(call *ast.Call, result object.Object)
+}
+
+// hook is checked before every statement, call and branch, so with none
+// set the evaluator pays a nil check and nothing else.
+var hook Hook
+
+// SetHook makes h the hook for all evaluation from now on and returns the
+// one it replaces. A nil h removes the hook.
+func SetHook(h Hook) Hook {
+	previous := hook
+	hook = h
+	return previous
+}
+
+// A BranchHook is a Hook that is also told which way each if expression
+// goes, for coverage. consequence is false when the condition was false,
+// whether or not there is an else.
+type BranchHook interface {
+	Hook
+	Branch(node *ast.IfExpression, consequence bool)
+}
@@ -0,0 +1,159 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// DefineMacros moves every top level `let name = macro(...)` out of program
+// and into env, so ExpandMacros can find them.
+func DefineMacros(program *ast.Program, env *object.Enviroment) {
+	statements := program.Statements[:0]
+	for _, s := range program.Statements {
+		let, ok := s.(*ast.LetStatement)
+		if !ok {
+			statements = append(statements, s)
+			continue
+		}
+		macro, ok := let.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, s)
+			continue
+		}
+		env.Set(let.Name.Value, &object.Macro{Params: macro.Params, Body: macro.Body, Env: env})
+	}
+	program.Statements = statements
+}
+
+// ExpandMacros replaces every call to a macro defined in env with the code
+// the macro returns. The expanded code takes the position of the call site,
+// so errors raised by it point at the macro call.
+func ExpandMacros(program *ast.Program, env *object.Enviroment) (*ast.Program, error) {
+	var err error
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
+		call, ok := node.(*ast.Call)
+		if !ok {
+			return node
+		}
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok {
+			return node
+		}
+		macro, ok := macroFor(ident, env)
+		if !ok {
+			return node
+		}
+		var result ast.Node
+		result, err = expandMacro(macro, call, ident.Token)
+		if err != nil {
+			return node
+		}
+		return result
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expanded.(*ast.Program), nil
+}
+
+func macroFor(ident *ast.Identifier, env *object.Enviroment) (*object.Macro, bool) {
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+func expandMacro(macro *object.Macro, call *ast.Call, site *token.Token) (ast.Node, error) {
+	if len(call.Arguments) != len(macro.Params) {
+		return nil, fmt.Errorf("%d:%d: wrong number of arguments to macro %s: want %d got %d",
+			site.Line, site.Column, site.Literal, len(macro.Params), len(call.Arguments))
+	}
+	env := object.NewEnclosedEnviroment(macro.Env)
+	for i, param := range macro.Params {
+		env.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+	}
+	evaluated := Eval(macro.Body, env)
+	if returnV, ok := evaluated.(*object.ReturnValue); ok {
+		evaluated = returnV.Value
+	}
+	if err, ok := evaluated.(*object.Error); ok {
+		return nil, fmt.Errorf("%d:%d: expanding macro %s: %s", site.Line, site.Column, site.Literal, err.Inspect())
+	}
+	quoted, ok := evaluated.(*object.Quote)
+	if !ok {
+		typ := object.ObjType(object.NULL)
+		if evaluated != nil {
+			typ = evaluated.Type()
+		}
+		return nil, fmt.Errorf("%d:%d: macro %s must return a quote, got %s", site.Line, site.Column, site.Literal, typ)
+	}
+	return atCallSite(quoted.Node, macro.Body, site), nil
+}
+
+// atCallSite moves the nodes that came from the macro body, or were made up
+// by unquote, to the position of site. Code passed in as arguments keeps its
+// own position.
+func atCallSite(node ast.Node, body *ast.BlockStatement, site *token.Token) ast.Node {
+	fromBody := map[*token.Token]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if t := tokenOf(n); t != nil {
+			fromBody[*t] = true
+		}
+		return true
+	})
+	return ast.Modify(ast.Clone(node), func(n ast.Node) ast.Node {
+		t := tokenOf(n)
+		if t == nil || *t == nil {
+			return n
+		}
+		if fromBody[*t] || (*t).Line == 0 {
+			moved := **t
+			moved.Line = site.Line
+			moved.Column = site.Column
+			*t = &moved
+		}
+		return n
+	})
+}
+
+// tokenOf returns a pointer to the token field of node so callers can
+// replace it, or nil for nodes that don't carry one.
+func tokenOf(node ast.Node) **token.Token {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		return &node.Token
+	case *ast.ExpressionStatement:
+		return &node.Token
+	case *ast.LetStatement:
+		return &node.Token
+	case *ast.ReturnStatement:
+		return &node.Token
+	case *ast.InfixExperssion:
+		return &node.Token
+	case *ast.PrefixExpression:
+		return &node.Token
+	case *ast.IfExpression:
+		return &node.Token
+	case *ast.FunctionLiteral:
+		return &node.Token
+	case *ast.MacroLiteral:
+		return &node.Token
+	case *ast.Call:
+		return &node.Token
+	case *ast.Identifier:
+		return &node.Token
+	case *ast.IntLiteral:
+		return &node.Token
+	case *ast.Boolean:
+		return &node.Token
+	}
+	return nil
+}
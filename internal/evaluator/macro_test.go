@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"quote(5);", "5"},
+		{"quote(5 + 8);", "(5 + 8)"},
+		{"quote(unquote(4 + 4));", "8"},
+		{"quote(8 + unquote(4 + 4));", "(8 + 8)"},
+		{"let foo = 8; quote(foo + unquote(foo));", "(foo + 8)"},
+		{"quote(unquote(true == false));", "false"},
+		{"let q = quote(4 + 4); quote(unquote(q) + 1);", "((4 + 4) + 1)"},
+	}
+	for _, tt := range input {
+		obj := Eval(parseProgram(t, tt.input), object.NewEnviroment())
+		q, ok := obj.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote got %T (%v)", obj, obj)
+		}
+		if q.Node.String() != tt.expected {
+			t.Fatalf("expected %s got %s", tt.expected, q.Node.String())
+		}
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+    let number = 1;
+    let mymacro = macro(x, y) { x + y; };
+    `
+	env := object.NewEnviroment()
+	program := parseProgram(t, input)
+	DefineMacros(program, env)
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement got %d", len(program.Statements))
+	}
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in enviroment")
+	}
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("expected *object.Macro got %T", obj)
+	}
+	if len(macro.Params) != 2 {
+		t.Fatalf("expected 2 params got %d", len(macro.Params))
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infix = macro() { quote(1 + 2); }; infix();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`let unless = macro(cond, cons, alt) {
+                quote(if (!(unquote(cond))) { unquote(cons); } else { unquote(alt); });
+            };
+            unless(10 > 5, 1, 2);`,
+			`if (!(10 > 5)) { 1; } else { 2; };`,
+		},
+	}
+	for _, tt := range input {
+		expected := parseProgram(t, tt.expected)
+		program := parseProgram(t, tt.input)
+		env := object.NewEnviroment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if expanded.String() != expected.String() {
+			t.Fatalf("expected %q got %q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestMacroExpansionKeepsCallSite(t *testing.T) {
+	input := `let broken = macro(x) {
+    quote(unquote(x) + nope);
+};
+let a = 1;
+broken(a);`
+	program := parseProgram(t, input)
+	macroEnv := object.NewEnviroment()
+	DefineMacros(program, macroEnv)
+	expanded, err := ExpandMacros(program, macroEnv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	obj := Eval(expanded, object.NewEnviroment())
+	e, ok := obj.(*object.Error)
+	if !ok {
+		t.Fatalf("expected error got %T", obj)
+	}
+	if e.Line != 5 || e.Column != 1 {
+		t.Fatalf("expected error at 5:1 got %d:%d (%s)", e.Line, e.Column, e.Message)
+	}
+	// the same macro can be expanded twice without the first expansion
+	// leaking into the second
+	program = parseProgram(t, `let twice = macro(x) { quote(unquote(x) * 2); }; twice(1); twice(3);`)
+	DefineMacros(program, macroEnv)
+	expanded, err = ExpandMacros(program, macroEnv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expanded.String() != "(1 * 2)\n(3 * 2)\n" {
+		t.Fatalf("got %q", expanded.String())
+	}
+}
+
+func TestMacroMustReturnQuote(t *testing.T) {
+	program := parseProgram(t, `let m = macro() { 1; }; m();`)
+	env := object.NewEnviroment()
+	DefineMacros(program, env)
+	if _, err := ExpandMacros(program, env); err == nil {
+		t.Fatalf("expected an error for a macro that doesn't return a quote")
+	}
+}
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+func isQuoteCall(node *ast.Call) bool {
+	ident, ok := node.Function.(*ast.Identifier)
+	return ok && ident.Value == "quote" && len(node.Arguments) == 1
+}
+
+func isUnquoteCall(node ast.Node) bool {
+	call, ok := node.(*ast.Call)
+	if !ok {
+		return false
+	}
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == "unquote" && len(call.Arguments) == 1
+}
+
+// quote wraps node without evaluating it, except for unquote(...) calls
+// inside it, which are evaluated in env and spliced back in as code.
+func quote(node ast.Node, env *object.Enviroment) object.Object {
+	var err object.Object
+	node = ast.Modify(ast.Clone(node), func(n ast.Node) ast.Node {
+		if err != nil || !isUnquoteCall(n) {
+			return n
+		}
+		call := n.(*ast.Call)
+		evaluated := Eval(call.Arguments[0], env)
+		if isError(evaluated) {
+			err = evaluated
+			return n
+		}
+		spliced, ok := objectToNode(evaluated)
+		if !ok {
+			err = errorAt(newError("can't unquote %s", evaluated.Type()), call.Token)
+			return n
+		}
+		return spliced
+	})
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+// objectToNode turns the result of an unquote back into code. The tokens it
+// makes have no position; macro expansion gives them the call site's.
+func objectToNode(obj object.Object) (ast.Node, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.NewToken(token.INT, fmt.Sprintf("%d", obj.Value))
+		return &ast.IntLiteral{Token: &t, Value: int64(obj.Value)}, true
+	case *object.Boolean:
+		var t token.Token
+		if obj.Value {
+			t = token.NewToken(token.TRUE, "true")
+		} else {
+			t = token.NewToken(token.FALSE, "false")
+		}
+		return &ast.Boolean{Token: &t, Value: obj.Value}, true
+	case *object.Quote:
+		return obj.Node, true
+	}
+	return nil, false
+}
@@ -0,0 +1,304 @@
+// Package format prints Monkey programs in the canonical style: four space
+// indentation, one statement per line, a semicolon after every statement
+// that doesn't end in a block, single spaces around infix operators and
+// only the parentheses the parser needs.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+const indentation = "    "
+
+// atom is the precedence of expressions that never need parentheses.
+const atom = parser.CALL + 1
+
+var errIncomplete = errors.New("the syntax tree is incomplete")
+
+// Source parses src and returns it formatted. Source that doesn't parse is
+// returned as an error and never formatted.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.New(string(src))
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, fmt.Errorf("syntax error: %s", strings.TrimSpace(errs[0]))
+	}
+	out, err := Node(program)
+	if err != nil {
+		return nil, err
+	}
+	// the parser gives up on some mistakes without saying so, so check that
+	// the formatted program still means the same thing before handing it out
+	l = lexer.New(string(out))
+	l.EmitComments(true)
+	check := parser.New(l).ParseProgram()
+	if check.String() != program.String() || len(check.Comments) != len(program.Comments) {
+		return nil, errors.New("formatting would change the meaning of the program, is it valid?")
+	}
+	return out, nil
+}
+
+// Node returns the formatted text of node, which has to be a program, a
+// statement or an expression. The comments of a program are printed above
+// the statement that follows them, or after the statement on whose line
+// they are.
+func Node(node ast.Node) ([]byte, error) {
+	p := &printer{}
+	switch n := node.(type) {
+	case *ast.Program:
+		p.comments = n.Comments
+		p.statements(n.Statements, nil)
+		p.flushComments(nil)
+		if p.out.Len() > 0 {
+			p.out.WriteString("\n")
+		}
+	case ast.Statement:
+		p.statement(n)
+	case ast.Expression:
+		p.expr(n, parser.LOWEST)
+	default:
+		return nil, fmt.Errorf("can't format %T", node)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.out.Bytes(), nil
+}
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+	err    error
+	// comments still to be printed, in source order
+	comments []*token.Token
+	// the source line of the last thing printed, for keeping blank lines
+	lastLine int
+	// set at the start of a block, where blank lines are dropped
+	first bool
+}
+
+func (p *printer) print(s ...string) {
+	for _, v := range s {
+		p.out.WriteString(v)
+	}
+}
+
+// commentsBefore reports whether there are comments left to print that
+// come before t.
+func (p *printer) commentsBefore(t *token.Token) bool {
+	return t != nil && len(p.comments) > 0 && before(p.comments[0], t)
+}
+
+func (p *printer) newline() {
+	p.out.WriteString("\n")
+	p.out.WriteString(strings.Repeat(indentation, p.indent))
+}
+
+// statements prints stmts one per line, with the comments that come before
+// end. A single blank line is kept where the source had one or more.
+func (p *printer) statements(stmts []ast.Statement, end *token.Token) {
+	for _, s := range stmts {
+		p.flushComments(ast.Start(s))
+		p.line(ast.Start(s))
+		p.statement(s)
+		last := ast.End(s)
+		if last != nil && last.Line != 0 {
+			p.lastLine = last.Line
+		}
+		// a comment on the line the statement ends on stays there
+		if len(p.comments) > 0 && last != nil && p.comments[0].Line == last.Line && before(p.comments[0], end) {
+			p.print(" ", p.comments[0].Literal)
+			p.lastLine = ast.CommentEndLine(p.comments[0])
+			p.comments = p.comments[1:]
+		}
+	}
+}
+
+// line starts a new line for something at the source position of t.
+func (p *printer) line(t *token.Token) {
+	if p.out.Len() > 0 || p.indent > 0 {
+		p.out.WriteString("\n")
+		if !p.first && t != nil && t.Line != 0 && p.lastLine != 0 && t.Line-p.lastLine > 1 {
+			p.out.WriteString("\n")
+		}
+	}
+	p.first = false
+	p.out.WriteString(strings.Repeat(indentation, p.indent))
+}
+
+// flushComments prints, each on its own line, the comments that come
+// before t, or all of them if t is nil.
+func (p *printer) flushComments(t *token.Token) {
+	for len(p.comments) > 0 && before(p.comments[0], t) {
+		c := p.comments[0]
+		p.line(c)
+		p.print(c.Literal)
+		p.lastLine = ast.CommentEndLine(c)
+		p.comments = p.comments[1:]
+	}
+}
+
+// before reports whether comment c comes before t in the source. Anything
+// comes before a nil t.
+func before(c, t *token.Token) bool {
+	if t == nil {
+		return true
+	}
+	return c.Line < t.Line || (c.Line == t.Line && c.Column < t.Column)
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		if s.Name == nil {
+			p.err = errIncomplete
+			return
+		}
+		p.print("let ", annotated(s.Name), " = ")
+		p.expr(s.Value, parser.LOWEST)
+		p.print(";")
+	case *ast.ReturnStatement:
+		p.print("return ")
+		p.expr(s.ReturnValue, parser.LOWEST)
+		p.print(";")
+	case *ast.ExpressionStatement:
+		p.expr(s.Expression, parser.LOWEST)
+		if _, ok := s.Expression.(*ast.IfExpression); !ok {
+			p.print(";")
+		}
+	case *ast.BlockStatement:
+		p.block(s)
+	default:
+		p.err = fmt.Errorf("can't format %T", s)
+	}
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if b == nil {
+		p.err = errIncomplete
+		return
+	}
+	if len(b.Statements) == 0 && !p.commentsBefore(b.Rbrace) {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.indent++
+	p.first = true
+	p.statements(b.Statements, b.Rbrace)
+	if b.Rbrace != nil {
+		p.flushComments(b.Rbrace)
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+// precedence returns how tightly e holds together, so the printer knows
+// when it needs parentheses around it.
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExperssion:
+		return parser.Precedence(e.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.Call:
+		return parser.CALL
+	}
+	return atom
+}
+
+// expr prints e, in parentheses if it binds looser than min.
+func (p *printer) expr(e ast.Expression, min int) {
+	if e == nil {
+		p.err = errIncomplete
+		return
+	}
+	if precedence(e) < min {
+		p.print("(")
+		defer p.print(")")
+	}
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.print(e.Value)
+	case *ast.IntLiteral:
+		p.print(e.Token.Literal)
+	case *ast.Boolean:
+		p.print(e.Token.Literal)
+	case *ast.PrefixExpression:
+		p.print(e.Operator)
+		p.expr(e.Right, parser.PREFIX)
+	case *ast.InfixExperssion:
+		prec := parser.Precedence(e.Token.Type)
+		p.expr(e.Left, prec)
+		p.print(" ", e.Operator, " ")
+		// operators are left associative, so an equally tight right side
+		// needs parentheses
+		p.expr(e.Right, prec+1)
+	case *ast.IfExpression:
+		p.print("if (")
+		p.expr(e.Condition, parser.LOWEST)
+		p.print(") ")
+		p.block(e.Consequence)
+		if e.Alternative != nil {
+			p.print(" else ")
+			p.block(e.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.print("fn")
+		p.params(e.Params)
+		if e.Result != nil {
+			p.print("-> ", e.Result.String(), " ")
+		}
+		p.block(e.Body)
+	case *ast.MacroLiteral:
+		p.print("macro")
+		p.params(e.Params)
+		p.block(e.Body)
+	case *ast.Call:
+		if _, ok := e.Function.(*ast.IfExpression); ok {
+			p.print("(")
+			p.expr(e.Function, parser.LOWEST)
+			p.print(")")
+		} else {
+			p.expr(e.Function, parser.CALL)
+		}
+		p.print("(")
+		for i, a := range e.Arguments {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expr(a, parser.LOWEST)
+		}
+		p.print(")")
+	default:
+		p.err = fmt.Errorf("can't format %T", e)
+	}
+}
+
+func (p *printer) params(params []*ast.Identifier) {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = annotated(param)
+	}
+	p.print("(", strings.Join(names, ", "), ") ")
+}
+
+// annotated returns the name of ident followed by its type annotation, if
+// it has one, like x: int.
+func annotated(ident *ast.Identifier) string {
+	if ident.Type == nil {
+		return ident.Value
+	}
+	return ident.Value + ": " + ident.Type.String()
+}
@@ -0,0 +1,106 @@
+package format
+
+import "testing"
+
+func TestSource(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=(1+2)", "let x = 1 + 2;\n"},
+		{"let x = 1 + 2 * 3;", "let x = 1 + 2 * 3;\n"},
+		{"let x = (1 + 2) * 3;", "let x = (1 + 2) * 3;\n"},
+		{"let x = 1 - (2 - 3);", "let x = 1 - (2 - 3);\n"},
+		{"let x = (1 - 2) - 3;", "let x = 1 - 2 - 3;\n"},
+		{"-(a + b); -a + b; !(a == b);", "-(a + b);\n-a + b;\n!(a == b);\n"},
+		{"add(1,2)", "add(1, 2);\n"},
+		{"let f = fn(a,b){return a+b}", "let f = fn(a, b) {\n    return a + b;\n};\n"},
+		{"let f = fn(){}", "let f = fn() {};\n"},
+		{
+			"if (x > t){\nreturn x + t;\n} else {\n        return 0;\n}\n",
+			"if (x > t) {\n    return x + t;\n} else {\n    return 0;\n}\n",
+		},
+		{
+			"let x = 1;\n\n\n\nlet y = 2;\nlet z = 3;",
+			"let x = 1;\n\nlet y = 2;\nlet z = 3;\n",
+		},
+		{
+			"let f = fn(x) {\n  if (x) { let y = fn() { 1 }; y(); }\n};",
+			"let f = fn(x) {\n    if (x) {\n        let y = fn() {\n            1;\n        };\n        y();\n    }\n};\n",
+		},
+		{"let m = macro(a) { quote(unquote(a)) };", "let m = macro(a) {\n    quote(unquote(a));\n};\n"},
+		{"let x:int=5", "let x: int = 5;\n"},
+		{"let f = fn(a:int,b)->int|null{a}", "let f = fn(a: int, b) -> int | null {\n    a;\n};\n"},
+		{"let g: (fn(int)->bool)|null = f", "let g: (fn(int) -> bool) | null = f;\n"},
+	}
+	for _, tt := range input {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("%q: unexpected error %s", tt.input, err)
+		}
+		if string(out) != tt.expected {
+			t.Fatalf("%q: expected\n%s\ngot\n%s", tt.input, tt.expected, out)
+		}
+		again, err := Source(out)
+		if err != nil {
+			t.Fatalf("%q: formatting the output failed: %s", tt.input, err)
+		}
+		if string(again) != string(out) {
+			t.Fatalf("%q: formatting is not stable:\n%s\nthen\n%s", tt.input, out, again)
+		}
+	}
+}
+
+func TestSourceRejectsBrokenPrograms(t *testing.T) {
+	input := []string{
+		"let x = ;",
+		"let = 5;",
+		"fn(x { x };",
+	}
+	for _, tt := range input {
+		if out, err := Source([]byte(tt)); err == nil {
+			t.Fatalf("%q: expected an error, got %q", tt, out)
+		}
+	}
+}
+
+func TestSourceKeepsComments(t *testing.T) {
+	input := `// the answer
+let x=42; // right here
+
+
+/* block
+   comment */
+let f = fn(a){
+// inside
+a*2 // double
+// before the brace
+};
+let g = fn() {
+    // only a comment
+};
+// at the end
+`
+	expected := `// the answer
+let x = 42; // right here
+
+/* block
+   comment */
+let f = fn(a) {
+    // inside
+    a * 2; // double
+    // before the brace
+};
+let g = fn() {
+    // only a comment
+};
+// at the end
+`
+	out, err := Source([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out)
+	}
+}
@@ -1,87 +1,219 @@
 package lexer
 
 import (
-	"log"
+	"bufio"
+	"io"
+	"strings"
 	"unicode"
 
 	"github.com/myselfBZ/interpreter/internal/token"
 )
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewReader(strings.NewReader(input))
+}
+
+// NewReader returns a lexer that reads its input from r as it goes, so the
+// whole script never has to be in memory at once.
+func NewReader(r io.Reader) *Lexer {
+	l := &Lexer{src: bufio.NewReader(r), line: 1}
+	l.next = l.readRune()
 	l.readChar()
 	return l
 }
 
 type Lexer struct {
-	input   string
-	pos     int
-	ch      byte
-	readPos int
+	src *bufio.Reader
+	// err is the first error reading src, other than io.EOF
+	err error
+	// ch is the current character and next the one after it
+	ch   rune
+	next rune
+	// line and column of ch, both starting at 1; columns count runes
+	line   int
+	column int
+	// while recording, readChar collects the characters it moves past
+	// into text
+	recording bool
+	text      strings.Builder
+	// emitComments makes NextToken return comments instead of skipping them
+	emitComments bool
+}
+
+// Err returns the error, if any, that stopped the lexer from reading its
+// input. The lexer behaves as if the input ended there.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// EmitComments makes NextToken return COMMENT tokens rather than skipping
+// comments, for tools that want to keep them.
+func (l *Lexer) EmitComments(emit bool) {
+	l.emitComments = emit
 }
 
-func isDigit(ch byte) bool {
-	return unicode.IsDigit(rune(ch))
+// isDigit reports whether ch can start a number. Only ASCII digits do, since
+// those are the only ones numbers can be written with.
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
 }
 
-func isLetter(ch byte) bool {
-	return unicode.IsLetter(rune(ch))
+// isLetter reports whether ch can start an identifier.
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-func (l *Lexer) peek() byte {
-	if l.readPos >= len(l.input) {
+// isIdentifierChar reports whether ch can appear in an identifier after
+// the first character.
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || unicode.IsDigit(ch)
+}
+
+func (l *Lexer) peek() rune {
+	return l.next
+}
+
+func (l *Lexer) readRune() rune {
+	r, _, err := l.src.ReadRune()
+	if err != nil {
+		if err != io.EOF && l.err == nil {
+			l.err = err
+		}
 		return 0
 	}
-	return l.input[l.readPos]
+	return r
+}
+
+// record starts collecting the characters the lexer moves past, beginning
+// with the current one.
+func (l *Lexer) record() {
+	l.recording = true
+	l.text.Reset()
+}
+
+// recorded stops collecting and returns what was collected since record,
+// not including the current character.
+func (l *Lexer) recorded() string {
+	l.recording = false
+	return l.text.String()
 }
 
 func (l *Lexer) skipWhiteSpace() {
-	for l.ch == ' ' || l.ch == '\n' {
+	for l.ch == ' ' || l.ch == '\n' || l.ch == '\t' || l.ch == '\r' {
 		l.readChar()
 	}
 }
 
 func (l *Lexer) readIdentifier() string {
-	var identifier string
-	for isLetter(l.ch) {
-		identifier += string(l.ch)
+	l.record()
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
-	return identifier
+	return l.recorded()
 }
 
 func (l *Lexer) readChar() {
-	if l.readPos >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPos]
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
 	}
-	l.pos = l.readPos
-	l.readPos += 1
+	if l.recording && l.ch != 0 {
+		l.text.WriteRune(l.ch)
+	}
+	l.ch = l.next
+	if l.ch != 0 {
+		l.next = l.readRune()
+	}
+	l.column++
 }
 
-func (l *Lexer) Tokenize() {
+// Tokens reads the rest of the input and returns its tokens, with their
+// positions, ending with the EOF token. Check Err afterwards to tell the
+// end of the input from a failed read.
+func (l *Lexer) Tokens() []token.Token {
+	var tokens []token.Token
 	for {
 		t := l.NextToken()
+		tokens = append(tokens, *t)
 		if t.Type == token.EOF {
-			log.Println("the end")
-			break
+			return tokens
 		}
-		log.Printf("Token Type: %s, Token Literal: %s", t.Type, t.Literal)
 	}
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+// readDigit reads an integer literal: decimal, or hex, octal or binary with
+// a 0x, 0o or 0b prefix, with '_' allowed between digits. Octal and binary
+// literals take any decimal digit so the parser can point out the wrong one.
 func (l *Lexer) readDigit() string {
-	var number string
-	for isDigit(l.ch) {
-		number += string(l.ch)
+	l.record()
+	digit := isDigit
+	if l.ch == '0' {
+		switch l.peek() {
+		case 'x', 'X':
+			digit = isHexDigit
+			fallthrough
+		case 'o', 'O', 'b', 'B':
+			l.readChar()
+			l.readChar()
+		}
+	}
+	for digit(l.ch) || l.ch == '_' {
 		l.readChar()
 	}
-	return number
+	return l.recorded()
 }
 
+// NextToken returns the next token in the input, tagged with the line and
+// column it starts at.
 func (l *Lexer) NextToken() *token.Token {
-	l.skipWhiteSpace()
+	for {
+		l.skipWhiteSpace()
+		line, column := l.line, l.column
+		t := l.readToken()
+		t.Line = line
+		t.Column = column
+		if t.Type != token.COMMENT || l.emitComments {
+			return t
+		}
+	}
+}
+
+// readLineComment reads a // comment up to, not including, the newline.
+func (l *Lexer) readLineComment() string {
+	l.record()
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return l.recorded()
+}
+
+// readBlockComment reads a /* */ comment, which may contain other block
+// comments. ok is false if the input ends before the comment does.
+func (l *Lexer) readBlockComment() (comment string, ok bool) {
+	l.record()
+	depth := 0
+	for l.ch != 0 {
+		if l.ch == '/' && l.peek() == '*' {
+			depth++
+			l.readChar()
+		} else if l.ch == '*' && l.peek() == '/' {
+			depth--
+			l.readChar()
+			if depth == 0 {
+				l.readChar()
+				return l.recorded(), true
+			}
+		}
+		l.readChar()
+	}
+	return l.recorded(), false
+}
+
+func (l *Lexer) readToken() *token.Token {
 	var t token.Token
 	switch l.ch {
 	case '=':
@@ -92,8 +224,30 @@ func (l *Lexer) NextToken() *token.Token {
 			t = token.NewToken(token.ASSIGN, string(l.ch))
 		}
 	case '-':
-		t = token.NewToken(token.MINUS, string(l.ch))
+		if l.peek() == '>' {
+			l.readChar()
+			t = token.NewToken(token.ARROW, "->")
+		} else {
+			t = token.NewToken(token.MINUS, string(l.ch))
+		}
+	case ':':
+		t = token.NewToken(token.COLON, string(l.ch))
+	case '|':
+		t = token.NewToken(token.PIPE, string(l.ch))
 	case '/':
+		switch l.peek() {
+		case '/':
+			t = token.NewToken(token.COMMENT, l.readLineComment())
+			return &t
+		case '*':
+			comment, ok := l.readBlockComment()
+			if !ok {
+				t = token.NewToken(token.ILLEGAL, comment)
+			} else {
+				t = token.NewToken(token.COMMENT, comment)
+			}
+			return &t
+		}
 		t = token.NewToken(token.DIVISION, string(l.ch))
 	case '+':
 		t = token.NewToken(token.PLUS, string(l.ch))
@@ -135,6 +289,10 @@ func (l *Lexer) NextToken() *token.Token {
 		t.Type = token.EOF
 	case '*':
 		t = token.NewToken(token.MULTIPLICATION, string(l.ch))
+	case '[':
+		t = token.NewToken(token.LBRACKET, string(l.ch))
+	case ']':
+		t = token.NewToken(token.RBRACKET, string(l.ch))
 	default:
 		if isDigit(l.ch) {
 			t.Literal = l.readDigit()
@@ -1 +1,196 @@
 package lexer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+func TestComments(t *testing.T) {
+	input := `// a line comment
+let x = 10 / 2; // after
+/* a /* nested */ block
+comment */ x;`
+	expected := []struct {
+		kind    token.TokenType
+		literal string
+		line    int
+		column  int
+	}{
+		{token.COMMENT, "// a line comment", 1, 1},
+		{token.LET, "let", 2, 1},
+		{token.IDENT, "x", 2, 5},
+		{token.ASSIGN, "=", 2, 7},
+		{token.INT, "10", 2, 9},
+		{token.DIVISION, "/", 2, 12},
+		{token.INT, "2", 2, 14},
+		{token.SEMICOLON, ";", 2, 15},
+		{token.COMMENT, "// after", 2, 17},
+		{token.COMMENT, "/* a /* nested */ block\ncomment */", 3, 1},
+		{token.IDENT, "x", 4, 12},
+		{token.SEMICOLON, ";", 4, 13},
+		{token.EOF, "", 4, 14},
+	}
+	l := New(input)
+	l.EmitComments(true)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.kind || tok.Literal != tt.literal {
+			t.Fatalf("token %d: expected %s %q got %s %q", i, tt.kind, tt.literal, tok.Type, tok.Literal)
+		}
+		if tok.Line != tt.line || tok.Column != tt.column {
+			t.Fatalf("token %d (%q): expected %d:%d got %d:%d", i, tok.Literal, tt.line, tt.column, tok.Line, tok.Column)
+		}
+	}
+
+	// without EmitComments the comments are skipped
+	l = New(input)
+	for _, tt := range expected {
+		if tt.kind == token.COMMENT {
+			continue
+		}
+		tok := l.NextToken()
+		if tok.Type != tt.kind {
+			t.Fatalf("expected %s got %s %q", tt.kind, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("1; /* never closed")
+	l.NextToken()
+	l.NextToken()
+	tok := l.NextToken()
+	if tok.Type != token.ILLEGAL {
+		t.Fatalf("expected ILLEGAL got %s %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "let my_var2 = größe;\r\n\tlet _π = 3;\r\nλx1 ≠"
+	expected := []struct {
+		kind    token.TokenType
+		literal string
+		line    int
+		column  int
+	}{
+		{token.LET, "let", 1, 1},
+		{token.IDENT, "my_var2", 1, 5},
+		{token.ASSIGN, "=", 1, 13},
+		{token.IDENT, "größe", 1, 15},
+		{token.SEMICOLON, ";", 1, 20},
+		{token.LET, "let", 2, 2},
+		{token.IDENT, "_π", 2, 6},
+		{token.ASSIGN, "=", 2, 9},
+		{token.INT, "3", 2, 11},
+		{token.SEMICOLON, ";", 2, 12},
+		{token.IDENT, "λx1", 3, 1},
+		{token.ILLEGAL, "≠", 3, 5},
+		{token.EOF, "", 3, 6},
+	}
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.kind || tok.Literal != tt.literal {
+			t.Fatalf("token %d: expected %s %q got %s %q", i, tt.kind, tt.literal, tok.Type, tok.Literal)
+		}
+		if tok.Line != tt.line || tok.Column != tt.column {
+			t.Fatalf("token %d (%q): expected %d:%d got %d:%d", i, tok.Literal, tt.line, tt.column, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestDigitsAfterLetters(t *testing.T) {
+	l := New("2x")
+	if tok := l.NextToken(); tok.Type != token.INT || tok.Literal != "2" {
+		t.Fatalf("expected INT 2 got %s %q", tok.Type, tok.Literal)
+	}
+	if tok := l.NextToken(); tok.Type != token.IDENT || tok.Literal != "x" {
+		t.Fatalf("expected IDENT x got %s %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	// a script much bigger than the reader's buffer, handed over one byte
+	// at a time
+	input := strings.Repeat("let größe = 1;\n", 2000)
+	l := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+	count := 0
+	for {
+		tok := l.NextToken()
+		if tok.Type == token.EOF {
+			if tok.Line != 2001 {
+				t.Fatalf("expected EOF on line 2001 got %d", tok.Line)
+			}
+			break
+		}
+		if tok.Type == token.IDENT && tok.Literal != "größe" {
+			t.Fatalf("unexpected identifier %q on line %d", tok.Literal, tok.Line)
+		}
+		count++
+	}
+	if count != 2000*5 {
+		t.Fatalf("expected %d tokens got %d", 2000*5, count)
+	}
+	if l.Err() != nil {
+		t.Fatalf("unexpected error %s", l.Err())
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	failure := errors.New("disk on fire")
+	l := NewReader(io.MultiReader(strings.NewReader("let x = 12"), iotest.ErrReader(failure)))
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+	if !errors.Is(l.Err(), failure) {
+		t.Fatalf("expected the read error to be reported, got %v", l.Err())
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tokens := New("let x = 5;\nx").Tokens()
+	expected := []token.Token{
+		{Type: token.LET, Literal: "let", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "x", Line: 1, Column: 5},
+		{Type: token.ASSIGN, Literal: "=", Line: 1, Column: 7},
+		{Type: token.INT, Literal: "5", Line: 1, Column: 9},
+		{Type: token.SEMICOLON, Literal: ";", Line: 1, Column: 10},
+		{Type: token.IDENT, Literal: "x", Line: 2, Column: 1},
+		{Type: token.EOF, Literal: "", Line: 2, Column: 2},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens got %d: %v", len(expected), len(tokens), tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Fatalf("token %d: expected %+v got %+v", i, expected[i], tokens[i])
+		}
+	}
+}
+
+func TestTypeAnnotationTokens(t *testing.T) {
+	tokens := New("x: int | null -> -1").Tokens()
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "x", Line: 1, Column: 1},
+		{Type: token.COLON, Literal: ":", Line: 1, Column: 2},
+		{Type: token.IDENT, Literal: "int", Line: 1, Column: 4},
+		{Type: token.PIPE, Literal: "|", Line: 1, Column: 8},
+		{Type: token.IDENT, Literal: "null", Line: 1, Column: 10},
+		{Type: token.ARROW, Literal: "->", Line: 1, Column: 15},
+		{Type: token.MINUS, Literal: "-", Line: 1, Column: 18},
+		{Type: token.INT, Literal: "1", Line: 1, Column: 19},
+		{Type: token.EOF, Literal: "", Line: 1, Column: 20},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens got %d: %v", len(expected), len(tokens), tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Fatalf("token %d: expected %+v got %+v", i, expected[i], tokens[i])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ConfigFile is the name of the configuration file monkey lint reads from
+// the current directory when it isn't given one.
+const ConfigFile = ".monkeylint.json"
+
+// A Config turns rules on and off. It is read from JSON like
+//
+//	{"rules": {"shadow": false, "unused": true}}
+//
+// Rules it doesn't mention are on.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Enabled reports whether the rule called name is on. A nil Config turns
+// every rule on.
+func (c *Config) Enabled(name string) bool {
+	if c == nil {
+		return true
+	}
+	on, ok := c.Rules[name]
+	return !ok || on
+}
+
+// LoadConfig reads the configuration in the file at path. Naming a rule
+// that doesn't exist is an error, so a typo doesn't go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var unknown []string
+	for name := range c.Rules {
+		if lookup(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s: unknown rules %s", path, strings.Join(unknown, ", "))
+	}
+	return &c, nil
+}
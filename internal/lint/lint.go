@@ -0,0 +1,531 @@
+// Package lint finds code in Monkey programs that is legal but likely a
+// mistake, like a binding nothing uses or a condition that never changes.
+//
+// Every finding comes from a rule, and rules can be turned off for a
+// whole project in a Config or for a single line with a comment:
+//
+//	let unused = 1; // lint:ignore unused
+//
+// A lint:ignore comment names the rules it silences, separated by commas,
+// and applies to the line it is on and the line after it.
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/tester"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// A Rule is a kind of mistake the linter looks for.
+type Rule struct {
+	Name string
+	Doc  string
+}
+
+// Rules are the rules the linter knows, all of them on by default.
+var Rules = []*Rule{
+	{"unused", "a let binds a name nothing uses; names starting with _ and the tests of test files are exempt"},
+	{"shadow", "a let or a parameter hides a binding of an enclosing function, or a builtin"},
+	{"unreachable", "a statement comes after a return and never runs"},
+	{"constant-if", "the condition of an if is always true or always false"},
+	{"self-compare", "a value is compared with itself"},
+	{"non-bool-if", "the condition of an if can't be a boolean, which fails when it runs"},
+}
+
+func lookup(name string) *Rule {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// A Finding is a spot a rule complains about.
+type Finding struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", f.Path, f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Lint returns what the rules config turns on find in program, which was
+// read from the file at path, in the order of their positions. program
+// should keep its comments, or lint:ignore comments can't work.
+func Lint(path string, program *ast.Program, config *Config) []*Finding {
+	l := &linter{
+		path:    path,
+		config:  config,
+		ignored: ignored(program.Comments),
+		test:    strings.HasSuffix(path, tester.Suffix),
+	}
+	l.scope = &scope{}
+	l.statements(program.Statements)
+	l.closeScope()
+	sortFindings(l.findings)
+	return l.findings
+}
+
+// ignored returns the rules the lint:ignore comments among comments
+// silence, by line.
+func ignored(comments []*token.Token) map[int]map[string]bool {
+	lines := map[int]map[string]bool{}
+	for _, c := range comments {
+		text := strings.TrimPrefix(c.Literal, "//")
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		rules, ok := strings.CutPrefix(strings.TrimSpace(text), "lint:ignore")
+		if !ok {
+			continue
+		}
+		// the comment covers its own line, or its last if it spans several,
+		// and the one after
+		last := c.Line + strings.Count(c.Literal, "\n")
+		for _, line := range []int{c.Line, last, last + 1} {
+			if lines[line] == nil {
+				lines[line] = map[string]bool{}
+			}
+			for _, r := range strings.Split(strings.TrimSpace(rules), ",") {
+				lines[line][strings.TrimSpace(r)] = true
+			}
+		}
+	}
+	return lines
+}
+
+func sortFindings(findings []*Finding) {
+	// insertion sort keeps findings at the same spot in the order they
+	// were made
+	for i := 1; i < len(findings); i++ {
+		for j := i; j > 0 && before(findings[j], findings[j-1]); j-- {
+			findings[j], findings[j-1] = findings[j-1], findings[j]
+		}
+	}
+}
+
+func before(a, b *Finding) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
+
+type linter struct {
+	path     string
+	config   *Config
+	ignored  map[int]map[string]bool
+	test     bool
+	findings []*Finding
+	scope    *scope
+}
+
+// A scope is the top level of a program or the body of a function or
+// macro. Like the evaluator, it ignores blocks: a let inside an if binds
+// in the enclosing function.
+type scope struct {
+	outer    *scope
+	bindings []*binding
+	// pending are the names used in functions inside the scope that
+	// weren't bound yet when the functions were read; a function can use
+	// names bound after it, as long as it is called after that
+	pending []*ast.Identifier
+}
+
+type binding struct {
+	name *ast.Identifier
+	// let is the statement that made the binding, nil for parameters
+	let  *ast.LetStatement
+	used bool
+}
+
+func (l *linter) report(tok *token.Token, rule, format string, a ...interface{}) {
+	if tok == nil || !l.config.Enabled(rule) || l.ignored[tok.Line][rule] {
+		return
+	}
+	l.findings = append(l.findings, &Finding{
+		Path:    l.path,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// find returns the latest binding of name in s, or nil.
+func (s *scope) find(name string) *binding {
+	for i := len(s.bindings) - 1; i >= 0; i-- {
+		if s.bindings[i].name.Value == name {
+			return s.bindings[i]
+		}
+	}
+	return nil
+}
+
+// use marks the binding ident refers to as used.
+func (l *linter) use(ident *ast.Identifier) {
+	for s := l.scope; s != nil; s = s.outer {
+		if b := s.find(ident.Value); b != nil {
+			b.used = true
+			return
+		}
+	}
+	l.scope.pending = append(l.scope.pending, ident)
+}
+
+// bind adds a binding for name to the current scope.
+func (l *linter) bind(name *ast.Identifier, let *ast.LetStatement) {
+	if l.scope.find(name.Value) == nil {
+		if outer := l.outerBinding(name.Value); outer != nil {
+			l.report(name.Token, "shadow", "%s shadows the %s declared at %d:%d", name.Value, name.Value, outer.name.Token.Line, outer.name.Token.Column)
+		} else if builtin(name.Value) {
+			l.report(name.Token, "shadow", "%s shadows the builtin %s", name.Value, name.Value)
+		}
+	}
+	l.scope.bindings = append(l.scope.bindings, &binding{name: name, let: let})
+}
+
+func (l *linter) outerBinding(name string) *binding {
+	for s := l.scope.outer; s != nil; s = s.outer {
+		if b := s.find(name); b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func builtin(name string) bool {
+	for _, b := range evaluator.Builtins() {
+		if b == name {
+			return true
+		}
+	}
+	return name == "quote" || name == "unquote"
+}
+
+func (l *linter) openScope() {
+	l.scope = &scope{outer: l.scope}
+}
+
+// closeScope resolves the names used before they were bound against the
+// scope being closed, hands the rest to the enclosing scope and reports
+// the lets nothing used.
+func (l *linter) closeScope() {
+	s := l.scope
+	var unresolved []*ast.Identifier
+	for _, ident := range s.pending {
+		b := s.find(ident.Value)
+		if b == nil {
+			unresolved = append(unresolved, ident)
+			continue
+		}
+		// a function calling itself doesn't make it used
+		if b.let == nil || !within(ident.Token, b.let) {
+			b.used = true
+		}
+	}
+	for _, b := range s.bindings {
+		if b.used || b.let == nil || strings.HasPrefix(b.name.Value, "_") {
+			continue
+		}
+		if l.test && s.outer == nil && strings.HasPrefix(b.name.Value, tester.Prefix) {
+			continue
+		}
+		l.report(b.name.Token, "unused", "%s is bound but never used", b.name.Value)
+	}
+	l.scope = s.outer
+	if l.scope != nil {
+		l.scope.pending = append(l.scope.pending, unresolved...)
+	}
+}
+
+// within reports whether tok is inside node.
+func within(tok *token.Token, node ast.Node) bool {
+	start, end := ast.Start(node), ast.End(node)
+	if start == nil || end == nil {
+		return false
+	}
+	after := tok.Line > start.Line || (tok.Line == start.Line && tok.Column >= start.Column)
+	before := tok.Line < end.Line || (tok.Line == end.Line && tok.Column <= end.Column)
+	return after && before
+}
+
+func (l *linter) statements(stmts []ast.Statement) {
+	terminated, reported := false, false
+	for _, s := range stmts {
+		if s == nil {
+			continue
+		}
+		// one finding for the whole run of dead statements
+		if terminated && !reported {
+			l.report(ast.Start(s), "unreachable", "unreachable code after return")
+			reported = true
+		}
+		l.statement(s)
+		terminated = terminated || terminates(s)
+	}
+}
+
+// terminates reports whether s always returns: it is a return, or an if
+// whose branches both end in one.
+func terminates(s ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.ExpressionStatement:
+		ifExp, ok := s.Expression.(*ast.IfExpression)
+		return ok && ifExp.Alternative != nil && blockTerminates(ifExp.Consequence) && blockTerminates(ifExp.Alternative)
+	}
+	return false
+}
+
+func blockTerminates(b *ast.BlockStatement) bool {
+	if b == nil {
+		return false
+	}
+	for _, s := range b.Statements {
+		if s != nil && terminates(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		l.expr(s.Value)
+		if s.Name != nil {
+			l.bind(s.Name, s)
+		}
+	case *ast.ReturnStatement:
+		l.expr(s.ReturnValue)
+	case *ast.ExpressionStatement:
+		l.expr(s.Expression)
+	case *ast.BlockStatement:
+		l.block(s)
+	}
+}
+
+func (l *linter) block(b *ast.BlockStatement) {
+	if b != nil {
+		l.statements(b.Statements)
+	}
+}
+
+func (l *linter) expr(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		l.use(e)
+	case *ast.PrefixExpression:
+		l.expr(e.Right)
+	case *ast.InfixExperssion:
+		l.selfCompare(e)
+		l.expr(e.Left)
+		l.expr(e.Right)
+	case *ast.IfExpression:
+		l.condition(e)
+		l.expr(e.Condition)
+		l.block(e.Consequence)
+		l.block(e.Alternative)
+	case *ast.FunctionLiteral:
+		l.function(e.Params, e.Body)
+	case *ast.MacroLiteral:
+		l.function(e.Params, e.Body)
+	case *ast.Call:
+		if ident, ok := e.Function.(*ast.Identifier); ok && ident.Value == "quote" && len(e.Arguments) == 1 {
+			// quoted code doesn't run here, but what it unquotes does
+			l.unquoted(e.Arguments[0])
+			return
+		}
+		l.expr(e.Function)
+		for _, a := range e.Arguments {
+			l.expr(a)
+		}
+	}
+}
+
+// unquoted lints the arguments of the unquote calls in node.
+func (l *linter) unquoted(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.Call)
+		if !ok {
+			return true
+		}
+		if ident, ok := call.Function.(*ast.Identifier); ok && ident.Value == "unquote" && len(call.Arguments) == 1 {
+			l.expr(call.Arguments[0])
+			return false
+		}
+		return true
+	})
+}
+
+func (l *linter) function(params []*ast.Identifier, body *ast.BlockStatement) {
+	l.openScope()
+	for _, p := range params {
+		if p != nil {
+			l.bind(p, nil)
+		}
+	}
+	l.block(body)
+	l.closeScope()
+}
+
+// condition checks the condition of e for the constant-if and non-bool-if
+// rules.
+func (l *linter) condition(e *ast.IfExpression) {
+	if e.Condition == nil {
+		return
+	}
+	if v, ok := constant(e.Condition); ok {
+		l.report(ast.Start(e.Condition), "constant-if", "the condition is always %v", v)
+		return
+	}
+	if kind := valueKind(e.Condition); kind != "" {
+		l.report(ast.Start(e.Condition), "non-bool-if", "the condition is %s, not a boolean", kind)
+	}
+}
+
+// constant returns the value of e if it is a boolean that doesn't depend
+// on anything but literals.
+func constant(e ast.Expression) (bool, bool) {
+	switch e := e.(type) {
+	case *ast.Boolean:
+		return e.Value, true
+	case *ast.PrefixExpression:
+		if e.Operator != "!" {
+			return false, false
+		}
+		// ! of anything that isn't a boolean is false, except of null
+		if v, ok := constant(e.Right); ok {
+			return !v, true
+		}
+		if _, ok := integer(e.Right); ok {
+			return false, true
+		}
+	case *ast.InfixExperssion:
+		if l, ok := integer(e.Left); ok {
+			if r, ok := integer(e.Right); ok {
+				return compareInts(e.Operator, l, r)
+			}
+		}
+		if l, ok := constant(e.Left); ok {
+			if r, ok := constant(e.Right); ok {
+				switch e.Operator {
+				case "==":
+					return l == r, true
+				case "!=":
+					return l != r, true
+				}
+			}
+		}
+	}
+	return false, false
+}
+
+// integer returns the value of e if it is an integer that doesn't depend
+// on anything but literals.
+func integer(e ast.Expression) (int64, bool) {
+	switch e := e.(type) {
+	case *ast.IntLiteral:
+		return e.Value, true
+	case *ast.PrefixExpression:
+		if v, ok := integer(e.Right); ok && e.Operator == "-" {
+			return -v, true
+		}
+	case *ast.InfixExperssion:
+		l, ok := integer(e.Left)
+		if !ok {
+			return 0, false
+		}
+		r, ok := integer(e.Right)
+		if !ok {
+			return 0, false
+		}
+		switch e.Operator {
+		case "+":
+			return l + r, true
+		case "-":
+			return l - r, true
+		case "*":
+			return l * r, true
+		case "/":
+			if r != 0 {
+				return l / r, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func compareInts(op string, l, r int64) (bool, bool) {
+	switch op {
+	case "==":
+		return l == r, true
+	case "!=":
+		return l != r, true
+	case "<":
+		return l < r, true
+	case ">":
+		return l > r, true
+	case "<=":
+		return l <= r, true
+	case ">=":
+		return l >= r, true
+	}
+	return false, false
+}
+
+// valueKind returns what e evaluates to if it is certain not to be a
+// boolean, like "an integer", or "" if it may be one.
+func valueKind(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.IntLiteral:
+		return "an integer"
+	case *ast.PrefixExpression:
+		if e.Operator == "-" {
+			return "an integer or null"
+		}
+	case *ast.InfixExperssion:
+		switch e.Operator {
+		case "+", "-", "*", "/":
+			return "an integer"
+		}
+	case *ast.FunctionLiteral:
+		return "a function"
+	}
+	return ""
+}
+
+// selfCompare reports comparisons of an expression with itself, unless it
+// calls something, which may give a different value each time.
+func (l *linter) selfCompare(e *ast.InfixExperssion) {
+	var always bool
+	switch e.Operator {
+	case "==", "<=", ">=":
+		always = true
+	case "!=", "<", ">":
+		always = false
+	default:
+		return
+	}
+	if e.Left == nil || e.Right == nil || e.Left.String() != e.Right.String() || calls(e.Left) {
+		return
+	}
+	l.report(e.Token, "self-compare", "%s is compared with itself, which is always %v", e.Left.String(), always)
+}
+
+func calls(e ast.Expression) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if _, ok := n.(*ast.Call); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
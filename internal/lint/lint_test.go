@@ -0,0 +1,130 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+func lintSource(t *testing.T, path, src string, config *Config) []string {
+	t.Helper()
+	l := lexer.New(src)
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: unexpected syntax errors %v", src, p.Errors())
+	}
+	var got []string
+	for _, f := range Lint(path, program, config) {
+		got = append(got, f.String())
+	}
+	return got
+}
+
+func TestRules(t *testing.T) {
+	input := []struct {
+		input    string
+		expected []string
+	}{
+		// unused
+		{"let x = 1;", []string{"a.monkey:1:5: x is bound but never used (unused)"}},
+		{"let x = 1; puts(x);", nil},
+		{"let _x = 1;", nil},
+		{"let f = fn() { let y = 2; 1 }; f();", []string{"a.monkey:1:20: y is bound but never used (unused)"}},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f();", nil},
+		{"let f = fn(n) { f(n) };", []string{"a.monkey:1:5: f is bound but never used (unused)"}},
+		{"let x = 1; let x = x + 1;", []string{"a.monkey:1:16: x is bound but never used (unused)"}},
+		{"let f = fn(unused) { 1 }; f(1);", nil},
+		{"let m = macro(a) { quote(unquote(a) + 1) }; let y = 1; m(y);", nil},
+
+		// shadow
+		{"let x = 1; let f = fn(x) { x }; f(x);", []string{"a.monkey:1:23: x shadows the x declared at 1:5 (shadow)"}},
+		{"let f = fn(a) { let b = fn() { let a = 1; a }; b() }; f(1);", []string{"a.monkey:1:36: a shadows the a declared at 1:12 (shadow)"}},
+		{"let puts = fn(x) { x }; puts(1);", []string{"a.monkey:1:5: puts shadows the builtin puts (shadow)"}},
+		{"let x = 1; let x = 2; puts(x);", []string{"a.monkey:1:5: x is bound but never used (unused)"}},
+
+		// unreachable
+		{"let f = fn() { return 1; puts(2); puts(3); }; f();", []string{"a.monkey:1:26: unreachable code after return (unreachable)"}},
+		{"let f = fn(c) { if (c) { return 1; } else { return 2; } puts(3); }; f(true);", []string{"a.monkey:1:57: unreachable code after return (unreachable)"}},
+		{"let f = fn(c) { if (c) { return 1; } puts(3); }; f(true);", nil},
+
+		// constant-if
+		{"if (true) { 1 }", []string{"a.monkey:1:5: the condition is always true (constant-if)"}},
+		{"if (1 + 1 > 3) { 1 }", []string{"a.monkey:1:5: the condition is always false (constant-if)"}},
+		{"if (!5) { 1 }", []string{"a.monkey:1:5: the condition is always false (constant-if)"}},
+		{"if (1 / 0 == 1) { 1 }", nil},
+		{"let c = true; if (c) { 1 }", nil},
+
+		// self-compare
+		{"let x = 1; x == x;", []string{"a.monkey:1:14: x is compared with itself, which is always true (self-compare)"}},
+		{"let x = 1; x + 1 < x + 1;", []string{"a.monkey:1:18: (x + 1) is compared with itself, which is always false (self-compare)"}},
+		{"let f = fn() { 1 }; f() == f();", nil},
+
+		// non-bool-if
+		{"let x = 1; if (x * 2) { 1 }", []string{"a.monkey:1:16: the condition is an integer, not a boolean (non-bool-if)"}},
+		{"if (fn() { true }) { 1 }", []string{"a.monkey:1:5: the condition is a function, not a boolean (non-bool-if)"}},
+		{"if (5) { 1 }", []string{"a.monkey:1:5: the condition is an integer, not a boolean (non-bool-if)"}},
+	}
+	for _, tt := range input {
+		got := lintSource(t, "a.monkey", tt.input, nil)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: expected %q got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestTestFiles(t *testing.T) {
+	src := "let test_add = fn() { 1 }; let helper = 1;"
+	expected := []string{"a_test.monkey:1:32: helper is bound but never used (unused)"}
+	if got := lintSource(t, "a_test.monkey", src, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+}
+
+func TestIgnoreComments(t *testing.T) {
+	src := `let a = 1; // lint:ignore unused
+// lint:ignore unused, shadow
+let puts = 2;
+/* lint:ignore
+   unused */
+let c = 3;
+let d = 4; // lint:ignore shadow
+`
+	expected := []string{"a.monkey:7:5: d is bound but never used (unused)"}
+	if got := lintSource(t, "a.monkey", src, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	config, err := LoadConfig(write("ok.json", `{"rules": {"unused": false, "shadow": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "let x = 1; if (x == x) { 1 }; let y = 2;"
+	expected := []string{"a.monkey:1:18: x is compared with itself, which is always true (self-compare)"}
+	if got := lintSource(t, "a.monkey", src, config); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+
+	if _, err := LoadConfig(write("typo.json", `{"rules": {"unsued": false, "shadw": false}}`)); err == nil || !strings.HasSuffix(err.Error(), "unknown rules shadw, unsued") {
+		t.Errorf("expected the unknown rules to be an error, got %v", err)
+	}
+	if _, err := LoadConfig(write("field.json", `{"rule": {}}`)); err == nil {
+		t.Errorf("expected an unknown field to be an error")
+	}
+}
@@ -0,0 +1,137 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// document is an open file, parsed as far as the parser got.
+type document struct {
+	uri     string
+	version int
+	lines   []string
+	program *ast.Program
+	errors  []string
+	top     *scope
+}
+
+func newDocument(uri string, version int, text string) *document {
+	l := lexer.New(text)
+	l.EmitComments(true)
+	p := parser.New(l)
+	d := &document{
+		uri:     uri,
+		version: version,
+		lines:   strings.Split(text, "\n"),
+		program: p.ParseProgram(),
+		errors:  p.Errors(),
+	}
+	d.top = scopes(d.program)
+	return d
+}
+
+// position converts a line and a column as the lexer counts them, from 1
+// and in runes, to an LSP position. Columns past the end of the line are
+// clipped to it.
+func (d *document) position(line, column int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{Line: max(line-1, 0)}
+	}
+	units := 0
+	for i, r := range []rune(d.lines[line-1]) {
+		if i >= column-1 {
+			break
+		}
+		units += utf16Len(r)
+	}
+	return Position{Line: line - 1, Character: units}
+}
+
+// column converts an LSP position to a line and a column as the lexer
+// counts them.
+func (d *document) column(p Position) (line, column int) {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return p.Line + 1, 1
+	}
+	units := 0
+	column = 1
+	for _, r := range d.lines[p.Line] {
+		if units >= p.Character {
+			break
+		}
+		units += utf16Len(r)
+		column++
+	}
+	return p.Line + 1, column
+}
+
+// tokenRange returns the range t covers.
+func (d *document) tokenRange(t *token.Token) Range {
+	return Range{
+		Start: d.position(t.Line, t.Column),
+		End:   d.position(t.Line, t.Column+len([]rune(t.Literal))),
+	}
+}
+
+// nodeRange returns the range from the start of node to the end of its
+// last token.
+func (d *document) nodeRange(node ast.Node) Range {
+	return Range{
+		Start: d.tokenRange(ast.Start(node)).Start,
+		End:   d.tokenRange(ast.End(node)).End,
+	}
+}
+
+// diagnostics turns the parser's errors into diagnostics. Errors that
+// carry a position mark the character there.
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, msg := range d.errors {
+		msg = strings.TrimSpace(msg)
+		var line, column int
+		if n, _ := fmt.Sscanf(msg, "%d:%d:", &line, &column); n == 2 {
+			_, msg, _ = strings.Cut(msg, ": ")
+		} else {
+			line, column = 1, 1
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    Range{Start: d.position(line, column), End: d.position(line, column+1)},
+			Severity: SeverityError,
+			Source:   "monkey",
+			Message:  msg,
+		})
+	}
+	return diagnostics
+}
+
+// identifierAt returns the identifier at line and column, or nil.
+func (d *document) identifierAt(line, column int) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(d.program, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if id, ok := n.(*ast.Identifier); ok && id.Token != nil {
+			t := id.Token
+			if t.Line == line && t.Column <= column && column <= t.Column+len([]rune(t.Literal)) {
+				found = id
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// utf16Len returns the number of UTF-16 code units r takes, which is what
+// LSP positions count.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,142 @@
+package lsp
+
+import (
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// A binding is a name made by a let statement or a parameter.
+type binding struct {
+	name *ast.Identifier
+	// let is the statement that made the binding, nil for parameters
+	let *ast.LetStatement
+	// fn is the function or macro a parameter belongs to
+	fn ast.Node
+}
+
+// A scope is the top level of a program or the body of a function or
+// macro. Like the evaluator, it ignores blocks: a let inside an if binds
+// in the enclosing function.
+type scope struct {
+	parent   *scope
+	node     ast.Node // nil for the top level
+	bindings []*binding
+	children []*scope
+}
+
+// scopes returns the top-level scope of program with the scopes of the
+// functions in it below.
+func scopes(program *ast.Program) *scope {
+	top := &scope{}
+	stack := []*scope{top}
+	var nodes []ast.Node
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n == nil {
+			switch nodes[len(nodes)-1].(type) {
+			case *ast.FunctionLiteral, *ast.MacroLiteral:
+				stack = stack[:len(stack)-1]
+			}
+			nodes = nodes[:len(nodes)-1]
+			return false
+		}
+		nodes = append(nodes, n)
+		cur := stack[len(stack)-1]
+		var params []*ast.Identifier
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil {
+				cur.bindings = append(cur.bindings, &binding{name: n.Name, let: n})
+			}
+			return true
+		case *ast.FunctionLiteral:
+			params = n.Params
+		case *ast.MacroLiteral:
+			params = n.Params
+		default:
+			return true
+		}
+		inner := &scope{parent: cur, node: n}
+		cur.children = append(cur.children, inner)
+		stack = append(stack, inner)
+		for _, p := range params {
+			if p != nil {
+				inner.bindings = append(inner.bindings, &binding{name: p, fn: n})
+			}
+		}
+		return true
+	})
+	return top
+}
+
+// before reports whether t starts at or before line and column.
+func before(t *token.Token, line, column int) bool {
+	return t.Line < line || (t.Line == line && t.Column <= column)
+}
+
+// contains reports whether the scope covers line and column, from the fn
+// keyword to the closing brace.
+func (s *scope) contains(line, column int) bool {
+	if s.node == nil {
+		return true
+	}
+	start, end := ast.Start(s.node), ast.End(s.node)
+	if !before(start, line, column) {
+		return false
+	}
+	endColumn := end.Column + len([]rune(end.Literal))
+	return line < end.Line || (line == end.Line && column <= endColumn)
+}
+
+// innermost returns the innermost scope covering line and column.
+func (s *scope) innermost(line, column int) *scope {
+	for _, c := range s.children {
+		if c.contains(line, column) {
+			return c.innermost(line, column)
+		}
+	}
+	return s
+}
+
+// lookup finds the binding name refers to at line and column: the last
+// one made before that point, in the innermost scope that has one. A
+// binding made later in the scope counts if there is none before, since a
+// function may well use a top-level name that is only bound further down.
+func (s *scope) lookup(name string, line, column int) *binding {
+	for sc := s.innermost(line, column); sc != nil; sc = sc.parent {
+		var found *binding
+		for _, b := range sc.bindings {
+			if b.name.Value != name {
+				continue
+			}
+			if found == nil || before(b.name.Token, line, column) {
+				found = b
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// visible returns the bindings that can be used at line and column, the
+// innermost first and each name once.
+func (s *scope) visible(line, column int) []*binding {
+	seen := make(map[string]bool)
+	var visible []*binding
+	for sc := s.innermost(line, column); sc != nil; sc = sc.parent {
+		for i := len(sc.bindings) - 1; i >= 0; i-- {
+			b := sc.bindings[i]
+			if seen[b.name.Value] {
+				continue
+			}
+			// only the top level may use names bound further down
+			if sc.parent != nil && !before(b.name.Token, line, column) {
+				continue
+			}
+			seen[b.name.Value] = true
+			visible = append(visible, b)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,383 @@
+// Package lsp implements a Language Server Protocol server for Monkey. It
+// keeps the open documents parsed and answers from the syntax tree: it
+// reports syntax errors, completes names, finds and describes the let
+// statements names come from and lists the top-level bindings.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/format"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// ErrNoShutdown is returned by Serve when the client sends exit without
+// asking the server to shut down first.
+var ErrNoShutdown = errors.New("lsp: exit without shutdown")
+
+type Server struct {
+	out      io.Writer
+	docs     map[string]*document
+	started  bool
+	shutdown bool
+}
+
+func NewServer() *Server {
+	return &Server{docs: make(map[string]*document)}
+}
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"initialized":                 nil,
+		"shutdown":                    (*Server).shutdownRequest,
+		"textDocument/didOpen":        (*Server).didOpen,
+		"textDocument/didChange":      (*Server).didChange,
+		"textDocument/didClose":       (*Server).didClose,
+		"textDocument/completion":     (*Server).completion,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+	}
+}
+
+// Serve reads messages from r and writes the replies to w until the
+// client sends exit or r ends.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	in := bufio.NewReader(r)
+	for {
+		body, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			if err := s.reply(nil, nil, &rpcError{codeParseError, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+			return nil
+		}
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches one request or notification and answers requests.
+// Only failing to write the answer is returned as an error.
+func (s *Server) handle(msg *message) error {
+	isRequest := len(msg.ID) > 0
+	h, ok := handlers[msg.Method]
+	var result interface{}
+	var err error
+	switch {
+	case !ok:
+		err = &rpcError{codeMethodNotFound, "method not found: " + msg.Method}
+	case !s.started && msg.Method != "initialize":
+		err = &rpcError{codeServerNotInitialized, "the server has not been initialized"}
+	case s.shutdown:
+		err = &rpcError{codeInvalidRequest, "the server is shutting down"}
+	case h != nil:
+		result, err = h(s, msg.Params)
+	}
+	if !isRequest {
+		// notifications get no answer, not even an error
+		return nil
+	}
+	var rpcErr *rpcError
+	if err != nil && !errors.As(err, &rpcErr) {
+		rpcErr = &rpcError{codeInvalidParams, err.Error()}
+	}
+	return s.reply(msg.ID, result, rpcErr)
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, rpcErr *rpcError) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	msg := &message{ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = data
+	}
+	return writeMessage(s.out, msg)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &message{Method: method, Params: data})
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	s.started = true
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:       SyncFull,
+			CompletionProvider:     &CompletionOptions{},
+			DefinitionProvider:     true,
+			HoverProvider:          true,
+			DocumentSymbolProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "monkey-lsp"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+// open parses text as the new content of a document and publishes its
+// syntax errors.
+func (s *Server) open(uri string, version int, text string) error {
+	d := newDocument(uri, version, text)
+	s.docs[uri] = d
+	return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: d.diagnostics(),
+	})
+}
+
+func (s *Server) didOpen(raw json.RawMessage) (interface{}, error) {
+	var params DidOpenTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc := params.TextDocument
+	return nil, s.open(doc.URI, doc.Version, doc.Text)
+}
+
+func (s *Server) didChange(raw json.RawMessage) (interface{}, error) {
+	var params DidChangeTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+	// with full syncs the last change holds the whole text
+	text := params.ContentChanges[len(params.ContentChanges)-1].Text
+	return nil, s.open(params.TextDocument.URI, params.TextDocument.Version, text)
+}
+
+func (s *Server) didClose(raw json.RawMessage) (interface{}, error) {
+	var params DidCloseTextDocumentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	delete(s.docs, params.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// document returns the open document uri.
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document %s is not open", uri)
+	}
+	return d, nil
+}
+
+// at decodes params for a request about a position in a document.
+func (s *Server) at(raw json.RawMessage) (d *document, line, column int, err error) {
+	var params TextDocumentPositionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, 0, 0, err
+	}
+	d, err = s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	line, column = d.column(params.Position)
+	return d, line, column, nil
+}
+
+func (s *Server) completion(raw json.RawMessage) (interface{}, error) {
+	d, line, column, err := s.at(raw)
+	if err != nil {
+		return nil, err
+	}
+	items := []CompletionItem{}
+	for _, b := range d.top.visible(line, column) {
+		items = append(items, CompletionItem{Label: b.name.Value, Kind: completionKind(b), Detail: detail(b)})
+	}
+	for _, name := range evaluator.Builtins() {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin"})
+	}
+	var keywords []string
+	for word := range token.Keywords {
+		keywords = append(keywords, word)
+	}
+	sort.Strings(keywords)
+	for _, word := range keywords {
+		items = append(items, CompletionItem{Label: word, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func completionKind(b *binding) int {
+	if b.let != nil && isFunction(b.let.Value) {
+		return CompletionFunction
+	}
+	return CompletionVariable
+}
+
+func isFunction(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.FunctionLiteral, *ast.MacroLiteral:
+		return true
+	}
+	return false
+}
+
+// detail describes a binding in a few words: the signature of a function
+// or what a parameter belongs to.
+func detail(b *binding) string {
+	if b.let == nil {
+		return "parameter of " + signature(b.fn)
+	}
+	if isFunction(b.let.Value) {
+		return signature(b.let.Value)
+	}
+	return ""
+}
+
+// signature returns "fn(a, b)" for a function or macro literal.
+func signature(node ast.Node) string {
+	var params []*ast.Identifier
+	keyword := "fn"
+	switch n := node.(type) {
+	case *ast.FunctionLiteral:
+		params = n.Params
+	case *ast.MacroLiteral:
+		params, keyword = n.Params, "macro"
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Value
+	}
+	return keyword + "(" + strings.Join(names, ", ") + ")"
+}
+
+// resolve finds the binding of the identifier at the requested position.
+// Both are nil if there is no identifier there or it isn't bound in the
+// document.
+func (s *Server) resolve(raw json.RawMessage) (*document, *ast.Identifier, *binding, error) {
+	d, line, column, err := s.at(raw)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	id := d.identifierAt(line, column)
+	if id == nil {
+		return d, nil, nil, nil
+	}
+	return d, id, d.top.lookup(id.Value, id.Token.Line, id.Token.Column), nil
+}
+
+func (s *Server) definition(raw json.RawMessage) (interface{}, error) {
+	d, _, b, err := s.resolve(raw)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return &Location{URI: d.uri, Range: d.tokenRange(b.name.Token)}, nil
+}
+
+func (s *Server) hover(raw json.RawMessage) (interface{}, error) {
+	d, id, b, err := s.resolve(raw)
+	if err != nil || id == nil {
+		return nil, err
+	}
+	var text string
+	switch {
+	case b != nil && b.let != nil:
+		text = "```monkey\n" + statement(b.let) + "\n```"
+		if doc := d.program.Doc(b.let); doc != "" {
+			text += "\n\n" + doc
+		}
+	case b != nil:
+		text = "```monkey\n" + b.name.Value + "\n```\n\nparameter of `" + signature(b.fn) + "`"
+	case isBuiltin(id.Value):
+		text = "```monkey\n" + id.Value + "\n```\n\nbuiltin function"
+	default:
+		return nil, nil
+	}
+	r := d.tokenRange(id.Token)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: &r}, nil
+}
+
+func isBuiltin(name string) bool {
+	for _, b := range evaluator.Builtins() {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+// statement returns the source of let, formatted.
+func statement(let *ast.LetStatement) string {
+	out, err := format.Node(let)
+	if err != nil {
+		// the parser left parts of it out
+		return let.String()
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (interface{}, error) {
+	var params DocumentSymbolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	symbols := []DocumentSymbol{}
+	for _, b := range d.top.bindings {
+		kind := SymbolVariable
+		if isFunction(b.let.Value) {
+			kind = SymbolFunction
+		}
+		symbols = append(symbols, DocumentSymbol{
+			Name:           b.name.Value,
+			Detail:         detail(b),
+			Kind:           kind,
+			Range:          d.nodeRange(b.let),
+			SelectionRange: d.tokenRange(b.name.Token),
+		})
+	}
+	return symbols, nil
+}
@@ -0,0 +1,238 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// client drives a Server over pipes the way an editor would.
+type client struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *bufio.Reader
+	id   int
+	done chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, in := io.Pipe()
+	out, serverOut := io.Pipe()
+	c := &client{t: t, in: in, out: bufio.NewReader(out), done: make(chan error, 1)}
+	go func() {
+		err := NewServer().Serve(serverIn, serverOut)
+		serverOut.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *client) send(msg *message) {
+	c.t.Helper()
+	if err := writeMessage(c.in, msg); err != nil {
+		c.t.Fatalf("writing %s: %s", msg.Method, err)
+	}
+}
+
+func (c *client) read() *message {
+	c.t.Helper()
+	body, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatalf("reading from the server: %s", err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatalf("bad message %s: %s", body, err)
+	}
+	return &msg
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	data, _ := json.Marshal(params)
+	c.send(&message{Method: method, Params: data})
+}
+
+// call sends a request and decodes its result into result. It returns
+// the error the server answered with, if any.
+func (c *client) call(method string, params, result interface{}) *rpcError {
+	c.t.Helper()
+	c.id++
+	id, _ := json.Marshal(c.id)
+	data, _ := json.Marshal(params)
+	c.send(&message{ID: id, Method: method, Params: data})
+	for {
+		msg := c.read()
+		if msg.Method != "" {
+			// a notification, not the answer
+			continue
+		}
+		if string(msg.ID) != string(id) {
+			c.t.Fatalf("%s: expected the answer to %s, got one to %s", method, id, msg.ID)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("%s: decoding %s: %s", method, msg.Result, err)
+			}
+		}
+		return nil
+	}
+}
+
+// diagnostics waits for the next diagnostics the server publishes.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	msg := c.read()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got %s", msg.Method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+const uri = "file:///tmp/double.monkey"
+
+const source = `// double returns twice x.
+let double = fn(x) {
+    let y = x * 2;
+    y
+};
+let answer = double(21);
+puts(answer);
+let s = 1; /* 😀 */ let t = s;
+`
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func TestServer(t *testing.T) {
+	c := newClient(t)
+
+	if err := c.call("textDocument/hover", at(0, 0), nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Fatalf("expected requests before initialize to fail, got %v", err)
+	}
+	var init InitializeResult
+	if err := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.Capabilities.TextDocumentSync != SyncFull || !init.Capabilities.DefinitionProvider || init.Capabilities.CompletionProvider == nil {
+		t.Fatalf("unexpected capabilities %+v", init.Capabilities)
+	}
+	c.notify("initialized", struct{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: source},
+	})
+	if d := c.diagnostics(); d.URI != uri || len(d.Diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %+v", d)
+	}
+
+	definitions := []struct {
+		from TextDocumentPositionParams
+		to   Range
+	}{
+		// double in double(21)
+		{at(5, 15), Range{Position{1, 4}, Position{1, 10}}},
+		// x in x * 2 is the parameter
+		{at(2, 12), Range{Position{1, 16}, Position{1, 17}}},
+		// s after the surrogate pair
+		{at(7, 28), Range{Position{7, 4}, Position{7, 5}}},
+	}
+	for _, tt := range definitions {
+		var loc Location
+		if err := c.call("textDocument/definition", tt.from, &loc); err != nil {
+			t.Fatal(err)
+		}
+		if loc.URI != uri || loc.Range != tt.to {
+			t.Errorf("definition at %+v: expected %+v, got %+v", tt.from.Position, tt.to, loc)
+		}
+	}
+
+	var hover Hover
+	if err := c.call("textDocument/hover", at(5, 15), &hover); err != nil {
+		t.Fatal(err)
+	}
+	expected := "```monkey\nlet double = fn(x) {\n    let y = x * 2;\n    y;\n};\n```\n\ndouble returns twice x."
+	if hover.Contents.Value != expected {
+		t.Errorf("expected hover\n%s\ngot\n%s", expected, hover.Contents.Value)
+	}
+	if err := c.call("textDocument/hover", at(6, 1), &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, "builtin function") {
+		t.Errorf("expected puts to be described as a builtin, got %s", hover.Contents.Value)
+	}
+
+	var items []CompletionItem
+	if err := c.call("textDocument/completion", at(3, 4), &items); err != nil {
+		t.Fatal(err)
+	}
+	labels := make(map[string]int)
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	for label, kind := range map[string]int{"y": CompletionVariable, "x": CompletionVariable, "double": CompletionFunction, "answer": CompletionVariable, "puts": CompletionFunction, "let": CompletionKeyword} {
+		if labels[label] != kind {
+			t.Errorf("expected %s with kind %d among the completions, got %v", label, kind, labels)
+		}
+	}
+
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"double", "answer", "s", "t"}) || symbols[0].Kind != SymbolFunction || symbols[1].Kind != SymbolVariable {
+		t.Errorf("unexpected symbols %+v", symbols)
+	}
+	if symbols[0].Range != (Range{Position{1, 0}, Position{4, 1}}) {
+		t.Errorf("expected double to span its let statement, got %+v", symbols[0].Range)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = ;\n"}},
+	})
+	d := c.diagnostics()
+	if d.Version != 2 || len(d.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic for version 2, got %+v", d)
+	}
+	if diag := d.Diagnostics[0]; diag.Message != "no prefix func for ;" || diag.Range.Start != (Position{0, 8}) {
+		t.Errorf("unexpected diagnostic %+v", diag)
+	}
+
+	if err := c.call("textDocument/formatting", struct{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected an unknown method to be reported, got %v", err)
+	}
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Fatalf("expected a clean exit, got %s", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t)
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrNoShutdown {
+		t.Fatalf("expected %v, got %v", ErrNoShutdown, err)
+	}
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/myselfBZ/interpreter/internal/ast"
@@ -17,6 +18,9 @@ const (
 	NULL        = "NULL"
     RETURN_VALUE = "RETURN_VALUE"
     ERROR_OBJ = "ERROR"
+    QUOTE_OBJ = "QUOTE"
+    MACRO_OBJ = "MACRO"
+    BUILTIN_OBJ = "BUILTIN"
 )
 
 type Object interface {
@@ -30,19 +34,127 @@ func NewEnviroment() *Enviroment{
     }
 }
 
+// NewEnclosedEnviroment creates an enviroment whose lookups fall back to
+// outer, used for function and macro bodies.
+func NewEnclosedEnviroment(outer *Enviroment) *Enviroment{
+    env := NewEnviroment()
+    env.outer = outer
+    return env
+}
+
+// NewFrame creates the enviroment of a call to a function the resolver
+// numbered the bindings of: names are its locals, which live in slots
+// rather than a map. Lookups fall back to outer.
+func NewFrame(outer *Enviroment, names []string) *Enviroment{
+    return &Enviroment{
+        outer: outer,
+        slots: make([]Object, len(names)),
+        names: names,
+    }
+}
+
 type Enviroment struct{
     store map[string]Object
+    outer *Enviroment
+    // slots hold the locals of a frame, names[i] the name of slots[i]; a
+    // nil slot isn't bound yet
+    slots []Object
+    names []string
 }
 
 func (e *Enviroment) Get(name string) (Object, bool) {
+    if obj := e.slot(name); obj != nil{
+        return obj, true
+    }
     obj, ok := e.store[name]
+    if !ok && e.outer != nil{
+        return e.outer.Get(name)
+    }
     return obj, ok
 }
 func (e *Enviroment) Set(name string, obj Object) Object {
+    for i, n := range e.names{
+        if n == name{
+            e.slots[i] = obj
+            return obj
+        }
+    }
+    if e.store == nil{
+        e.store = make(map[string]Object)
+    }
     e.store[name] = obj
     return obj
 }
 
+func (e *Enviroment) slot(name string) Object{
+    for i, n := range e.names{
+        if n == name{
+            return e.slots[i]
+        }
+    }
+    return nil
+}
+
+// GetSlot returns the value in slot of the frame depth enviroments out
+// from e. It reports false if that slot isn't bound yet, or isn't there,
+// and the name has to be looked up instead.
+func (e *Enviroment) GetSlot(depth, slot int) (Object, bool) {
+    for ; depth > 0 && e != nil; depth--{
+        e = e.outer
+    }
+    if e == nil || slot >= len(e.slots) || e.slots[slot] == nil{
+        return nil, false
+    }
+    return e.slots[slot], true
+}
+
+// SetSlot binds slot of e, a frame, to obj. It reports false if e has no
+// such slot.
+func (e *Enviroment) SetSlot(slot int, obj Object) bool {
+    if slot >= len(e.slots){
+        return false
+    }
+    e.slots[slot] = obj
+    return true
+}
+
+// Outer returns the enviroment e is enclosed in, nil for the outermost.
+func (e *Enviroment) Outer() *Enviroment{
+    return e.outer
+}
+
+// LocalNames returns the names bound in e itself, sorted.
+func (e *Enviroment) LocalNames() []string{
+    names := make([]string, 0, len(e.store)+len(e.names))
+    for i, name := range e.names{
+        if e.slots[i] != nil{
+            names = append(names, name)
+        }
+    }
+    for name := range e.store{
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Names returns every name Get can find in e, including those of the
+// enviroments it is enclosed in, sorted and without duplicates.
+func (e *Enviroment) Names() []string{
+    seen := make(map[string]bool)
+    for env := e; env != nil; env = env.outer{
+        for _, name := range env.LocalNames(){
+            seen[name] = true
+        }
+    }
+    names := make([]string, 0, len(seen))
+    for name := range seen{
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
 type Integer struct {
 	Value int
 }
@@ -90,20 +202,33 @@ func (r *ReturnValue) Inspect() string{
 
 type Error struct{
     Message string
+    // position of the node that caused the error, zero if unknown
+    Line   int
+    Column int
 }
 
 func (e *Error) Type() ObjType{
     return ERROR_OBJ
 }
 func (e *Error) Inspect() string{
+    if e.Line > 0{
+        return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+    }
     return e.Message
 }
 
 
 type Function struct{
     Params []*ast.Identifier
+    // Result is the annotation of what the function returns, or nil
+    Result ast.Type
     Body   *ast.BlockStatement
     Env    *Enviroment
+    // Locals name the slots of the frame a call gets, nil if the
+    // resolver didn't number them
+    Locals []string
+    // Doc is the doc comment of the function's literal
+    Doc    string
 }
 
 func (f *Function) Type() ObjType {
@@ -125,3 +250,56 @@ func (f *Function) Inspect() string{
 }
 
 
+// Quote holds an unevaluated piece of code, produced by quote(...)
+type Quote struct{
+    Node ast.Node
+}
+
+func (q *Quote) Type() ObjType{
+    return QUOTE_OBJ
+}
+func (q *Quote) Inspect() string{
+    return "QUOTE(" + q.Node.String() + ")"
+}
+
+
+type Macro struct{
+    Params []*ast.Identifier
+    Body   *ast.BlockStatement
+    Env    *Enviroment
+}
+
+func (m *Macro) Type() ObjType{
+    return MACRO_OBJ
+}
+func (m *Macro) Inspect() string{
+    var out bytes.Buffer
+    params := []string{}
+    for _, p := range m.Params{
+        params = append(params, p.String())
+    }
+    out.WriteString("macro")
+    out.WriteString("(")
+    out.WriteString(strings.Join(params, ", "))
+    out.WriteString(") {\n")
+    out.WriteString(m.Body.String())
+    out.WriteString("\n}")
+    return out.String()
+}
+
+
+// BuiltinFunction is the Go side of a function that comes with the
+// interpreter.
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct{
+    Name string
+    Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjType{
+    return BUILTIN_OBJ
+}
+func (b *Builtin) Inspect() string{
+    return "builtin " + b.Name
+}
@@ -30,8 +30,14 @@ func (p *Parser) currentTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
 
+// errorAt records an error about tok, prefixed with its position as
+// "line:column: ".
+func (p *Parser) errorAt(tok *token.Token, format string, a ...interface{}) {
+	p.errors = append(p.errors, fmt.Sprintf("%d:%d: ", tok.Line, tok.Column)+fmt.Sprintf(format, a...))
+}
+
 func (p *Parser) noPrefixExpression(t token.TokenType) {
-	p.errors = append(p.errors, fmt.Sprintf("no prefix func for %s\n", t))
+	p.errorAt(p.curToken, "no prefix func for %s", t)
 }
 
 func (p *Parser) curPrecedence() int {
@@ -47,3 +53,12 @@ func (p *Parser) peekPrecedence() int {
 	}
 	return LOWEST
 }
+
+// Precedence returns how tightly the infix operator t binds, LOWEST for
+// tokens that aren't infix operators.
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/myselfBZ/interpreter/internal/ast"
 	"github.com/myselfBZ/interpreter/internal/lexer"
@@ -46,6 +47,7 @@ type Parser struct {
 	curToken  *token.Token
 	errors    []string
 	peekToken *token.Token
+	comments  []*token.Token
 	prefixFns map[token.TokenType]parsePrefix
 	infixFns  map[token.TokenType]parseInfix
 }
@@ -53,14 +55,15 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		lexer:     l,
-		curToken:  l.NextToken(),
-		peekToken: l.NextToken(),
 		prefixFns: make(map[token.TokenType]parsePrefix),
 		infixFns:  make(map[token.TokenType]parseInfix),
 	}
+	p.curToken = p.readToken()
+	p.peekToken = p.readToken()
 	//prefix
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpressions)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.IF, p.parseIfExpression)
@@ -92,12 +95,26 @@ func (p *Parser) ParseProgram() *ast.Program {
 		}
 		p.nextToken()
 	}
+	program.Comments = p.comments
+	program.SetDocs()
 	return program
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
-	p.peekToken = p.lexer.NextToken()
+	p.peekToken = p.readToken()
+}
+
+// readToken returns the next token that isn't a comment. Comments only
+// reach the parser if the lexer was told to emit them, and are kept aside
+// for the program.
+func (p *Parser) readToken() *token.Token {
+	t := p.lexer.NextToken()
+	for t.Type == token.COMMENT {
+		p.comments = append(p.comments, t)
+		t = p.lexer.NextToken()
+	}
+	return t
 }
 
 func (p *Parser) Errors() []string {
@@ -148,8 +165,14 @@ func (p *Parser) parseLet() ast.Statement {
 		return nil
 	}
 	node.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.expectPeekToken(token.COLON) {
+		p.nextToken()
+		if node.Name.Type = p.parseType(); node.Name.Type == nil {
+			return nil
+		}
+	}
 	if !p.expectPeekToken(token.ASSIGN) {
-		p.errors = append(p.errors, fmt.Sprintf("expected '=' got %s\n", p.peekToken.Literal))
+		p.errorAt(p.peekToken, "expected '=' got %s", p.peekToken.Literal)
 		return nil
 	}
 	p.nextToken()
@@ -171,15 +194,48 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 }
 
 func (p *Parser) parseInt() ast.Expression {
-	number, err := strconv.Atoi(p.curToken.Literal)
+	number, err := parseIntLiteral(p.curToken.Literal)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("%s is not a number", p.curToken.Literal))
+		p.errorAt(p.curToken, "%s", err)
 		return nil
 	}
-	node := &ast.IntLiteral{Token: p.curToken, Value: int64(number)}
+	node := &ast.IntLiteral{Token: p.curToken, Value: number}
 	return node
 }
 
+// parseIntLiteral reads a decimal, 0x hex, 0o octal or 0b binary literal
+// with optional '_' separators between digits.
+func parseIntLiteral(literal string) (int64, error) {
+	base, digits, kind := 10, literal, "decimal"
+	if len(literal) > 1 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			base, digits, kind = 16, literal[2:], "hexadecimal"
+		case 'o', 'O':
+			base, digits, kind = 8, literal[2:], "octal"
+		case 'b', 'B':
+			base, digits, kind = 2, literal[2:], "binary"
+		}
+	}
+	if digits == "" {
+		return 0, fmt.Errorf("%s literal %s has no digits", kind, literal)
+	}
+	if digits[0] == '_' || digits[len(digits)-1] == '_' || strings.Contains(digits, "__") {
+		return 0, fmt.Errorf("'_' must separate digits in %s", literal)
+	}
+	digits = strings.ReplaceAll(digits, "_", "")
+	for _, d := range digits {
+		if v, err := strconv.ParseInt(string(d), 16, 64); err != nil || int(v) >= base {
+			return 0, fmt.Errorf("invalid digit %q in %s literal %s", d, kind, literal)
+		}
+	}
+	number, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("integer literal %s doesn't fit in 64 bits", literal)
+	}
+	return number, nil
+}
+
 func (p *Parser) parseIdent() ast.Expression {
 	node := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	return node
@@ -191,7 +247,7 @@ func (p *Parser) parsePrefixOps() ast.Expression {
 
 	p.nextToken()
 
-	node.Right = p.parseExpression(LOWEST)
+	node.Right = p.parseExpression(PREFIX)
 
 	return node
 }
@@ -208,7 +264,7 @@ func (p *Parser) parseGroupedExpressions() ast.Expression {
 	p.nextToken()
 	exprsn := p.parseExpression(LOWEST)
 	if !p.peekTokenIs(token.RPAREN) {
-		p.errors = append(p.errors, fmt.Sprintf("expected '(' . Got %s", p.curToken.Literal))
+		p.errorAt(p.curToken, "expected '(' . Got %s", p.curToken.Literal)
 		return nil
 	}
 	p.nextToken()
@@ -222,7 +278,7 @@ func (p *Parser) parseBoolean() ast.Expression {
 func (p *Parser) parseIfExpression() ast.Expression {
 	node := &ast.IfExpression{Token: p.curToken}
 	if !p.expectPeekToken(token.LPAREN) {
-		p.errors = append(p.errors, fmt.Sprintf("expected ) got %s", p.peekToken.Literal))
+		p.errorAt(p.peekToken, "expected ) got %s", p.peekToken.Literal)
 		return nil
 	}
 	p.nextToken()
@@ -253,6 +309,9 @@ func (p *Parser) parseBlockStatements() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	if p.currentTokenIs(token.RBRACE) {
+		node.Rbrace = p.curToken
+	}
 	return node
 }
 
@@ -261,7 +320,13 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	if !p.expectPeekToken(token.LPAREN) {
 		return nil
 	}
-	node.Params = p.parseParams()
+	node.Params = p.parseParams(true)
+	if p.expectPeekToken(token.ARROW) {
+		p.nextToken()
+		if node.Result = p.parseType(); node.Result == nil {
+			return nil
+		}
+	}
 	if !p.expectPeekToken(token.LBRACE) {
 		return nil
 	}
@@ -272,20 +337,46 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return node
 }
 
-func (p *Parser) parseParams() []*ast.Identifier {
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	node := &ast.MacroLiteral{Token: p.curToken}
+	if !p.expectPeekToken(token.LPAREN) {
+		return nil
+	}
+	node.Params = p.parseParams(false)
+	if !p.expectPeekToken(token.LBRACE) {
+		return nil
+	}
+	node.Body = p.parseBlockStatements()
+	if !p.currentTokenIs(token.RBRACE) {
+		return nil
+	}
+	return node
+}
+
+// parseParams parses a parameter list. If typed is set the parameters
+// may have type annotations, as a function's may and a macro's may not.
+func (p *Parser) parseParams(typed bool) []*ast.Identifier {
 	idents := []*ast.Identifier{}
 	if p.expectPeekToken(token.RPAREN) {
 		return idents
 	}
 	p.nextToken()
-	ident1 := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	idents = append(idents, ident1)
-	for p.expectPeekToken(token.COMMA) {
-		p.nextToken()
+	for {
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if typed && p.expectPeekToken(token.COLON) {
+			p.nextToken()
+			if ident.Type = p.parseType(); ident.Type == nil {
+				return nil
+			}
+		}
 		idents = append(idents, ident)
+		if !p.expectPeekToken(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 	if !p.expectPeekToken(token.RPAREN) {
+		p.errorAt(p.peekToken, errorExpectedToken, token.RPAREN, p.peekToken.Type, p.peekToken.Literal)
 		return nil
 	}
 	return idents
@@ -294,12 +385,15 @@ func (p *Parser) parseParams() []*ast.Identifier {
 func (p *Parser) parseCall(f ast.Expression) ast.Expression {
 	node := &ast.Call{Token: p.curToken, Function: f}
 	node.Arguments = p.parseCallArguements()
+	if p.currentTokenIs(token.RPAREN) {
+		node.Rparen = p.curToken
+	}
 	return node
 }
 
 func (p *Parser) parseCallArguements() []ast.Expression {
 	var arguments []ast.Expression
-	if p.peekTokenIs(token.RBRACE) {
+	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
 		return arguments
 	}
@@ -317,3 +411,74 @@ func (p *Parser) parseCallArguements() []ast.Expression {
 	}
 	return arguments
 }
+
+// parseType parses a type annotation starting at the current token: a
+// name, a function type like fn(int) -> bool, or a union of them like
+// int | null. Parentheses group, for a union that holds a function type.
+func (p *Parser) parseType() ast.Type {
+	t := p.parseTypeAtom()
+	if t == nil || !p.peekTokenIs(token.PIPE) {
+		return t
+	}
+	union := &ast.UnionType{Types: []ast.Type{t}}
+	for p.expectPeekToken(token.PIPE) {
+		p.nextToken()
+		if t = p.parseTypeAtom(); t == nil {
+			return nil
+		}
+		union.Types = append(union.Types, t)
+	}
+	return union
+}
+
+func (p *Parser) parseTypeAtom() ast.Type {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+	case token.LPAREN:
+		p.nextToken()
+		t := p.parseType()
+		if t == nil {
+			return nil
+		}
+		if !p.expectPeekToken(token.RPAREN) {
+			p.errorAt(p.peekToken, errorExpectedToken, token.RPAREN, p.peekToken.Type, p.peekToken.Literal)
+			return nil
+		}
+		return t
+	case token.FUNCTION:
+		node := &ast.FunctionType{Token: p.curToken}
+		if !p.expectPeekToken(token.LPAREN) {
+			p.errorAt(p.peekToken, errorExpectedToken, token.LPAREN, p.peekToken.Type, p.peekToken.Literal)
+			return nil
+		}
+		if !p.expectPeekToken(token.RPAREN) {
+			for {
+				p.nextToken()
+				t := p.parseType()
+				if t == nil {
+					return nil
+				}
+				node.Params = append(node.Params, t)
+				if !p.expectPeekToken(token.COMMA) {
+					break
+				}
+			}
+			if !p.expectPeekToken(token.RPAREN) {
+				p.errorAt(p.peekToken, errorExpectedToken, token.RPAREN, p.peekToken.Type, p.peekToken.Literal)
+				return nil
+			}
+		}
+		if !p.expectPeekToken(token.ARROW) {
+			p.errorAt(p.peekToken, "expected -> and the result type of the function type, got %s", p.peekToken.Literal)
+			return nil
+		}
+		p.nextToken()
+		if node.Result = p.parseType(); node.Result == nil {
+			return nil
+		}
+		return node
+	}
+	p.errorAt(p.curToken, "expected a type, got %s", p.curToken.Literal)
+	return nil
+}
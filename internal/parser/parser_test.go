@@ -353,3 +353,186 @@ func TestFunctionLiteral(t *testing.T) {
 	}
 	// honestly, i am not quite fan of ttd. I like manual testing
 }
+
+func TestPrefixPrecedence(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"-a + b;", "((-a) + b)"},
+		{"!true == false;", "((!true) == false)"},
+		{"-(a + b);", "(-(a + b))"},
+		{"-f(1);", "(-f(1))"},
+		{"-a * b + c;", "(((-a) * b) + c)"},
+		{"!a == !b;", "((!a) == (!b))"},
+	}
+	for _, tt := range input {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Fatalf("expected %s got %s", tt.expected, got)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// unrelated
+
+// adds one
+// to x
+let inc = fn(x) { x + 1 }; // trailing
+let y = inc(1);`
+	l := lexer.New(input)
+	l.EmitComments(true)
+	p := New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("expected 2 statements got %d", len(program.Statements))
+	}
+	if len(program.Comments) != 4 {
+		t.Fatalf("expected 4 comments got %d", len(program.Comments))
+	}
+	doc := program.CommentsBefore(program.Statements[0])
+	if len(doc) != 2 || doc[0].Literal != "// adds one" || doc[1].Literal != "// to x" {
+		t.Fatalf("unexpected comments before the first statement: %v", doc)
+	}
+	if doc := program.CommentsBefore(program.Statements[1]); len(doc) != 0 {
+		t.Fatalf("expected no comments before the second statement got %v", doc)
+	}
+	fn := program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if fn.Doc != "adds one\nto x" {
+		t.Fatalf("expected the function's doc to be the comments above it, got %q", fn.Doc)
+	}
+}
+
+func TestIntLiteralBases(t *testing.T) {
+	input := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF;", 255},
+		{"0Xff;", 255},
+		{"0o755;", 493},
+		{"0b1010;", 10},
+		{"1_000_000;", 1000000},
+		{"0xdead_beef;", 0xdeadbeef},
+		{"0;", 0},
+		{"007;", 7},
+		{"9223372036854775807;", 9223372036854775807},
+	}
+	for _, tt := range input {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser errors: %v", tt.input, p.Errors())
+		}
+		exprsn := program.Statements[0].(*ast.ExpressionStatement)
+		intLiteral, ok := exprsn.Expression.(*ast.IntLiteral)
+		if !ok {
+			t.Fatalf("%s: expected IntLiteral got %T", tt.input, exprsn.Expression)
+		}
+		if intLiteral.Value != tt.expected {
+			t.Fatalf("%s: expected %d got %d", tt.input, tt.expected, intLiteral.Value)
+		}
+	}
+}
+
+func TestInvalidIntLiterals(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808;", "1:1: integer literal 9223372036854775808 doesn't fit in 64 bits"},
+		{"let x = 0xFFFFFFFFFFFFFFFFF;", "1:9: integer literal 0xFFFFFFFFFFFFFFFFF doesn't fit in 64 bits"},
+		{"0b102;", `1:1: invalid digit '2' in binary literal 0b102`},
+		{"0o8;", `1:1: invalid digit '8' in octal literal 0o8`},
+		{"0x;", "1:1: hexadecimal literal 0x has no digits"},
+		{"1__0;", "1:1: '_' must separate digits in 1__0"},
+		{"10_;", "1:1: '_' must separate digits in 10_"},
+	}
+	for _, tt := range input {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("%s: expected an error", tt.input)
+		}
+		if p.Errors()[0] != tt.expected {
+			t.Fatalf("%s: expected %q got %q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = ;", "1:9: no prefix func for ;"},
+		{"let x 5;", "1:7: expected '=' got 5"},
+		{"if x { 1 }", "1:4: expected ) got x"},
+		{"let f = fn(a, b {\n};", `1:17: expected:")" got:"{ {"`},
+		{"1 +\n  ;", "2:3: no prefix func for ;"},
+	}
+	for _, tt := range input {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("%q: expected an error", tt.input)
+		}
+		if p.Errors()[0] != tt.expected {
+			t.Fatalf("%q: expected %q got %q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int=5\n"},
+		{"let f = fn(a: int, b) -> bool { a == b };", "let f=fn (a: int, b) -> bool {\n(a == b)\n}\n"},
+		{"let f: fn(int, bool) -> int | null = g;", "let f: fn(int, bool) -> int | null=g\n"},
+		{"let f: (fn() -> int) | null = g;", "let f: (fn() -> int) | null=g\n"},
+		{"let f: fn(fn(int) -> int) -> fn() -> bool = g;", "let f: fn(fn(int) -> int) -> fn() -> bool=g\n"},
+	}
+	for _, tt := range input {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, p.Errors())
+		}
+		if program.String() != tt.expected {
+			t.Fatalf("%q: expected %q got %q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: = 5;", "1:8: expected a type, got ="},
+		{"let f: fn(int) = g;", "1:16: expected -> and the result type of the function type, got ="},
+		{"let f = fn(a: int b) { a };", `1:19: expected:")" got:"IDENT b"`},
+		{"let m = macro(a: int) { a };", `1:16: expected:")" got:": :"`},
+	}
+	for _, tt := range errors {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("%q: expected an error", tt.input)
+		}
+		if p.Errors()[0] != tt.expected {
+			t.Fatalf("%q: expected %q got %q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
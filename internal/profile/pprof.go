@@ -0,0 +1,165 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// WriteProfile writes the profile in the gzipped protocol buffer format
+// of pprof, see https://github.com/google/pprof/blob/main/proto/profile.proto.
+// Every sample holds a call stack with the calls it made and the time
+// spent in it, so `go tool pprof` shows both.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	var out encoder
+	index := map[string]int64{}
+	str := func(s string) int64 {
+		i, ok := index[s]
+		if !ok {
+			i = int64(len(index))
+			index[s] = i
+		}
+		return i
+	}
+	str("")
+	valueType := func(typ, unit string) []byte {
+		var vt encoder
+		vt.int64(1, str(typ))
+		vt.int64(2, str(unit))
+		return vt.bytes()
+	}
+
+	// sample_type
+	out.message(1, valueType("calls", "count"))
+	out.message(1, valueType("time", "nanoseconds"))
+
+	// sample, in a stable order
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	locations := map[location]uint64{}
+	var order []location
+	for _, key := range keys {
+		s := p.samples[key]
+		var ids []uint64
+		for _, loc := range s.stack {
+			id, ok := locations[loc]
+			if !ok {
+				id = uint64(len(locations) + 1)
+				locations[loc] = id
+				order = append(order, loc)
+			}
+			ids = append(ids, id)
+		}
+		var sample encoder
+		sample.packed(1, ids)
+		sample.packed(2, []uint64{uint64(s.calls), uint64(s.time)})
+		out.message(2, sample.bytes())
+	}
+
+	// location
+	for _, loc := range order {
+		var line, l encoder
+		line.uint64(1, uint64(loc.fn.id))
+		line.int64(2, int64(loc.line))
+		l.uint64(1, locations[loc])
+		l.message(4, line.bytes())
+		out.message(4, l.bytes())
+	}
+
+	// function
+	for _, fn := range p.Functions() {
+		var f encoder
+		f.uint64(1, uint64(fn.id))
+		f.int64(2, str(fn.Name))
+		f.int64(3, str(fn.Name))
+		if fn.Line > 0 {
+			f.int64(4, str(p.path))
+			f.int64(5, int64(fn.Line))
+		}
+		out.message(5, f.bytes())
+	}
+
+	// time_nanos, duration_nanos, period_type, period and
+	// default_sample_type, before the string table since they add to it
+	var rest encoder
+	rest.int64(9, p.start.UnixNano())
+	rest.int64(10, int64(p.elapsed))
+	rest.message(11, valueType("time", "nanoseconds"))
+	rest.int64(12, 1)
+	rest.int64(14, str("time"))
+
+	// string_table
+	table := make([]string, len(index))
+	for s, i := range index {
+		table[i] = s
+	}
+	for _, s := range table {
+		out.string(6, s)
+	}
+	out.buf = append(out.buf, rest.buf...)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(out.bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// encoder writes protocol buffer fields.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) bytes() []byte {
+	return e.buf
+}
+
+func (e *encoder) varint(x uint64) {
+	for x >= 0x80 {
+		e.buf = append(e.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	e.buf = append(e.buf, byte(x))
+}
+
+func (e *encoder) key(field int, wireType uint64) {
+	e.varint(uint64(field)<<3 | wireType)
+}
+
+// uint64 writes a varint field, leaving it out if it's zero like proto3.
+func (e *encoder) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	e.key(field, 0)
+	e.varint(x)
+}
+
+func (e *encoder) int64(field int, x int64) {
+	e.uint64(field, uint64(x))
+}
+
+// string writes a length delimited field even when s is empty, since
+// the string table needs its empty first entry.
+func (e *encoder) string(field int, s string) {
+	e.key(field, 2)
+	e.varint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) message(field int, msg []byte) {
+	e.key(field, 2)
+	e.varint(uint64(len(msg)))
+	e.buf = append(e.buf, msg...)
+}
+
+func (e *encoder) packed(field int, xs []uint64) {
+	var values encoder
+	for _, x := range xs {
+		values.varint(x)
+	}
+	e.message(field, values.bytes())
+}
@@ -0,0 +1,252 @@
+// Package profile measures where Monkey programs spend their time. It
+// follows the program's own calls through the evaluator's hook, so the
+// stacks it records are Monkey functions, not the evaluator's Go frames,
+// and the calls are counted exactly rather than sampled.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+// A Function is what the profile knows about one Monkey function, or
+// builtin, or the top level of the program.
+type Function struct {
+	// Name is the name the function was bound to with let, "fn@line:column"
+	// for anonymous functions and "main" for the top level
+	Name string
+	// Line is where the function is defined, 0 for builtins
+	Line  int
+	Calls int
+	// Total is the time from calls to returns, counting recursive calls
+	// once; Self leaves out the time spent in the functions it called
+	Total time.Duration
+	Self  time.Duration
+
+	id int
+	// active counts the calls in progress, for recursion
+	active int
+}
+
+type frame struct {
+	fn *Function
+	// line is the line the frame is running, or calling from
+	line  int
+	start time.Time
+}
+
+// sample is the time spent and the calls made with a call stack.
+type sample struct {
+	// stack holds the locations, innermost first
+	stack []location
+	calls int64
+	time  time.Duration
+}
+
+type location struct {
+	fn   *Function
+	line int
+}
+
+type Profiler struct {
+	path string
+	// functions by the body of their literal, or by name for builtins
+	functions map[interface{}]*Function
+	// names of the functions bound with let
+	names   map[*ast.BlockStatement]string
+	frames  []*frame
+	samples map[string]*sample
+	start   time.Time
+	last    time.Time
+	elapsed time.Duration
+	// now is time.Now, but tests can stop the clock
+	now func() time.Time
+}
+
+// New returns a profiler for programs read from the file at path, which
+// is the file name the profile refers to.
+func New(path string) *Profiler {
+	return &Profiler{
+		path:      path,
+		functions: map[interface{}]*Function{},
+		names:     map[*ast.BlockStatement]string{},
+		samples:   map[string]*sample{},
+		now:       time.Now,
+	}
+}
+
+// Run evaluates program in env while profiling it and returns what it
+// evaluates to. Only one program can be profiled at a time, since the
+// profiler is the evaluator's hook while it runs.
+func (p *Profiler) Run(program *ast.Program, env *object.Enviroment) object.Object {
+	ast.Inspect(program, func(n ast.Node) bool {
+		if let, ok := n.(*ast.LetStatement); ok {
+			if fn, ok := let.Value.(*ast.FunctionLiteral); ok && let.Name != nil {
+				p.names[fn.Body] = let.Name.Value
+			}
+		}
+		return true
+	})
+	main := p.function("main", "main", 1)
+	main.Calls++
+	main.active++
+	p.start = p.now()
+	p.last = p.start
+	p.frames = []*frame{{fn: main, line: 1, start: p.start}}
+
+	previous := evaluator.SetHook(p)
+	defer evaluator.SetHook(previous)
+	result := evaluator.Eval(program, env)
+
+	p.tick()
+	main.Total += p.last.Sub(p.start)
+	main.active--
+	p.frames = nil
+	p.elapsed = p.last.Sub(p.start)
+	return result
+}
+
+func (p *Profiler) function(key interface{}, name string, line int) *Function {
+	fn, ok := p.functions[key]
+	if !ok {
+		fn = &Function{Name: name, Line: line, id: len(p.functions) + 1}
+		p.functions[key] = fn
+	}
+	return fn
+}
+
+// tick charges the time since the last event to the innermost frame and
+// to the stack it is running in.
+func (p *Profiler) tick() {
+	now := p.now()
+	elapsed := now.Sub(p.last)
+	p.last = now
+	if elapsed <= 0 {
+		return
+	}
+	p.frames[len(p.frames)-1].fn.Self += elapsed
+	p.sample().time += elapsed
+}
+
+// sample returns the sample for the current stack.
+func (p *Profiler) sample() *sample {
+	var key strings.Builder
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		fmt.Fprintf(&key, "%d:%d;", p.frames[i].fn.id, p.frames[i].line)
+	}
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{}
+		for i := len(p.frames) - 1; i >= 0; i-- {
+			s.stack = append(s.stack, location{p.frames[i].fn, p.frames[i].line})
+		}
+		p.samples[key.String()] = s
+	}
+	return s
+}
+
+// Statement implements evaluator.Hook and moves the innermost frame on to
+// the line of stmt.
+func (p *Profiler) Statement(stmt ast.Statement, env *object.Enviroment) {
+	start := ast.Start(stmt)
+	if start == nil {
+		return
+	}
+	p.tick()
+	p.frames[len(p.frames)-1].line = start.Line
+}
+
+// Call implements evaluator.Hook and enters fn.
+func (p *Profiler) Call(call *ast.Call, fn object.Object) {
+	p.tick()
+	if start := ast.Start(call); start != nil {
+		p.frames[len(p.frames)-1].line = start.Line
+	}
+	var f *Function
+	switch fn := fn.(type) {
+	case *object.Function:
+		line := fn.Body.Token.Line
+		name, ok := p.names[fn.Body]
+		if !ok {
+			name = fmt.Sprintf("fn@%d:%d", line, fn.Body.Token.Column)
+		}
+		f = p.function(fn.Body, name, line)
+	case *object.Builtin:
+		f = p.function(fn.Name, fn.Name, 0)
+	default:
+		// not a function, applying it fails right away
+		name := call.Function.String()
+		f = p.function(name, name, 0)
+	}
+	f.Calls++
+	f.active++
+	p.frames = append(p.frames, &frame{fn: f, line: f.Line, start: p.last})
+	p.sample().calls++
+}
+
+// Return implements evaluator.Hook and leaves the innermost function.
+func (p *Profiler) Return(call *ast.Call, result object.Object) {
+	p.tick()
+	top := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+	top.fn.active--
+	if top.fn.active == 0 {
+		top.fn.Total += p.last.Sub(top.start)
+	}
+}
+
+// Functions returns the functions that ran, the ones that took the most
+// time by themselves first.
+func (p *Profiler) Functions() []*Function {
+	functions := make([]*Function, 0, len(p.functions))
+	for _, fn := range p.functions {
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		a, b := functions[i], functions[j]
+		if a.Self != b.Self {
+			return a.Self > b.Self
+		}
+		return a.id < b.id
+	})
+	return functions
+}
+
+// WriteSummary writes a table of the functions with their calls, total
+// and self times.
+func (p *Profiler) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "calls\ttotal\t\tself\t\t function\n")
+	for _, fn := range p.Functions() {
+		where := "builtin"
+		if fn.Line > 0 {
+			where = fmt.Sprintf("%s:%d", p.path, fn.Line)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t %s (%s)\n", fn.Calls,
+			round(fn.Total), percent(fn.Total, p.elapsed),
+			round(fn.Self), percent(fn.Self, p.elapsed), fn.Name, where)
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+func percent(d, of time.Duration) string {
+	if of <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(d)/float64(of))
+}
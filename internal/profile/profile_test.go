@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+const script = `let fib = fn(n) {
+    if (n < 2) {
+        return n;
+    }
+    fib(n - 1) + fib(n - 2)
+};
+let twice = fn(f, x) { f(f(x)) };
+puts(twice(fn(x) { x + 1 }, 1));
+fib(5)
+`
+
+// run profiles script on a clock that moves a millisecond every time the
+// profiler looks at it.
+func run(t *testing.T) *Profiler {
+	t.Helper()
+	p := parser.New(lexer.New(script))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	prof := New("fib.monkey")
+	clock := time.Unix(0, 0)
+	prof.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	result := prof.Run(program, object.NewEnviroment())
+	if i, ok := result.(*object.Integer); !ok || i.Value != 5 {
+		t.Fatalf("expected 5, got %v", result)
+	}
+	return prof
+}
+
+func TestFunctions(t *testing.T) {
+	prof := run(t)
+	functions := map[string]*Function{}
+	var self time.Duration
+	for _, fn := range prof.Functions() {
+		functions[fn.Name] = fn
+		self += fn.Self
+	}
+	if self != prof.elapsed {
+		t.Errorf("expected the self times to add up to %s, got %s", prof.elapsed, self)
+	}
+	expected := []struct {
+		name  string
+		line  int
+		calls int
+	}{
+		{"main", 1, 1},
+		{"fib", 1, 15},
+		{"twice", 7, 1},
+		{"fn@8:18", 8, 2},
+		{"puts", 0, 1},
+	}
+	if len(functions) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(functions))
+	}
+	for _, tt := range expected {
+		fn := functions[tt.name]
+		if fn == nil {
+			t.Errorf("%s is missing", tt.name)
+			continue
+		}
+		if fn.Line != tt.line || fn.Calls != tt.calls {
+			t.Errorf("%s: expected line %d and %d calls, got line %d and %d calls", tt.name, tt.line, tt.calls, fn.Line, fn.Calls)
+		}
+		if fn.Self > fn.Total || fn.Total > prof.elapsed {
+			t.Errorf("%s: self %s, total %s of %s", tt.name, fn.Self, fn.Total, prof.elapsed)
+		}
+	}
+	// twice calls the anonymous function twice, both within its own call
+	if twice, inner := functions["twice"], functions["fn@8:18"]; twice.Total != twice.Self+inner.Total {
+		t.Errorf("expected twice to take %s, got %s", twice.Self+inner.Total, twice.Total)
+	}
+	if main := functions["main"]; main.Total != prof.elapsed {
+		t.Errorf("expected main to take all of %s, got %s", prof.elapsed, main.Total)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	var out strings.Builder
+	if err := run(t).WriteSummary(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected a header and five functions, got\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 7 || fields[0] != "15" || fields[5] != "fib" || fields[6] != "(fib.monkey:1)" {
+		t.Errorf("expected fib to come first, got %q", lines[1])
+	}
+	if !strings.Contains(out.String(), " puts (builtin)\n") {
+		t.Errorf("expected puts in the summary, got\n%s", out.String())
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(t).WriteProfile(&out); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first field is sample_type, the string table ends up with the
+	// names of the functions and the file
+	if len(data) == 0 || data[0] != 1<<3|2 {
+		t.Fatalf("expected the profile to start with a sample type, got % x", data[:min(len(data), 8)])
+	}
+	for _, s := range []string{"calls", "nanoseconds", "fib", "twice", "fn@8:18", "puts", "fib.monkey"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("expected %q in the string table", s)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var e encoder
+	e.uint64(1, 150)
+	e.uint64(2, 0)
+	e.string(3, "")
+	e.packed(4, []uint64{3, 270})
+	expected := []byte{0x08, 0x96, 0x01, 0x1a, 0x00, 0x22, 0x03, 0x03, 0x8e, 0x02}
+	if !bytes.Equal(e.bytes(), expected) {
+		t.Fatalf("expected % x, got % x", expected, e.bytes())
+	}
+}
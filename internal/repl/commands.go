@@ -0,0 +1,190 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/tidwall/pretty"
+)
+
+// A command is one of the colon commands the prompt understands besides
+// Monkey code.
+type command struct {
+	name  string
+	args  string
+	short string
+	// code is set if the argument is Monkey code, which may span lines
+	code bool
+	run  func(s *session, arg string)
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"tokens", "<code>", "print the tokens of code", true, (*session).tokens},
+		{"ast", "[--json] <code>", "print the syntax tree of code", true, (*session).ast},
+		{"env", "", "list the bindings made so far", false, (*session).listEnv},
+		{"type", "<code>", "print the type of the value of code", true, (*session).typeOf},
+		{"load", "<file>", "run a script, keeping its bindings", false, (*session).load},
+		{"reset", "", "forget every binding and macro", false, (*session).resetCmd},
+		{"save", "<file>", "write the bindings to file as a script", false, (*session).save},
+		{"restore", "<file>", "replace the bindings with those saved in file", false, (*session).restore},
+		{"time", "<code>", "run code and print how long it took", true, (*session).time},
+		{"help", "", "list the commands", false, (*session).help},
+	}
+}
+
+// lookup splits an entry like ":ast 1 + 2" into its command and argument.
+// The command is nil if there is no such command.
+func lookup(entry string) (*command, string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(entry, ":"), " ")
+	for _, c := range commands {
+		if c.name == name {
+			return c, strings.TrimSpace(arg)
+		}
+	}
+	return nil, ""
+}
+
+// command runs a colon command.
+func (s *session) command(entry string) {
+	c, arg := lookup(entry)
+	if c == nil {
+		name, _, _ := strings.Cut(entry, " ")
+		s.report("", 0, 0, fmt.Sprintf("unknown command %s, :help lists them", name))
+		return
+	}
+	if c.args != "" && arg == "" && !strings.HasPrefix(c.args, "[") {
+		fmt.Fprintf(s.out, "usage: :%s %s\n", c.name, c.args)
+		return
+	}
+	c.run(s, arg)
+}
+
+func (s *session) help(string) {
+	w := tabwriter.NewWriter(s.out, 0, 8, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, ":%s %s\t%s\n", c.name, c.args, c.short)
+	}
+	w.Flush()
+	fmt.Fprintln(s.out, "Ctrl-C drops a half-typed entry; exit, quit or Ctrl-C at an empty prompt leave.")
+}
+
+func (s *session) tokens(src string) {
+	l := lexer.New(src)
+	l.EmitComments(true)
+	w := tabwriter.NewWriter(s.out, 0, 8, 2, ' ', 0)
+	for _, t := range l.Tokens() {
+		fmt.Fprintf(w, "%d:%d\t%s\t%q\n", t.Line, t.Column, t.Type, t.Literal)
+	}
+	w.Flush()
+}
+
+func (s *session) ast(arg string) {
+	asJSON := false
+	if rest, ok := strings.CutPrefix(arg, "--json"); ok {
+		asJSON = true
+		arg = strings.TrimSpace(rest)
+	}
+	program, ok := s.parse(arg)
+	if !ok {
+		return
+	}
+	if !asJSON {
+		printTree(s, program)
+		return
+	}
+	data, err := ast.MarshalJSON(program)
+	if err != nil {
+		s.report("", 0, 0, err.Error())
+		return
+	}
+	s.out.Write(pretty.Pretty(data))
+}
+
+// printTree prints the nodes under node one per line, indented by depth.
+func printTree(s *session, node ast.Node) {
+	depth := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		fmt.Fprintf(s.out, "%s%s\n", strings.Repeat("  ", depth), describe(n))
+		depth++
+		return true
+	})
+}
+
+// describe names the kind of node n, with the operator or value that sets
+// it apart from others of its kind.
+func describe(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Identifier:
+		return "Identifier " + n.Value
+	case *ast.IntLiteral:
+		return "IntLiteral " + n.Token.Literal
+	case *ast.Boolean:
+		return fmt.Sprintf("Boolean %v", n.Value)
+	case *ast.InfixExperssion:
+		return "InfixExpression " + n.Operator
+	case *ast.PrefixExpression:
+		return "PrefixExpression " + n.Operator
+	case *ast.NamedType:
+		return "NamedType " + n.Name
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+}
+
+func (s *session) listEnv(string) {
+	w := tabwriter.NewWriter(s.out, 0, 8, 2, ' ', 0)
+	for _, name := range s.env.Names() {
+		obj, _ := s.env.Get(name)
+		fmt.Fprintf(w, "%s\t%s\n", name, obj.Type())
+	}
+	for _, name := range s.macroEnv.Names() {
+		obj, _ := s.macroEnv.Get(name)
+		fmt.Fprintf(w, "%s\t%s\n", name, obj.Type())
+	}
+	w.Flush()
+}
+
+// typeOf evaluates src in a scope of its own, so the bindings it makes are
+// dropped again.
+func (s *session) typeOf(src string) {
+	obj, ok := s.eval(src, object.NewEnclosedEnviroment(s.env))
+	if !ok {
+		return
+	}
+	if obj == nil {
+		fmt.Fprintln(s.out, "no value")
+		return
+	}
+	fmt.Fprintln(s.out, obj.Type())
+}
+
+func (s *session) load(path string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		s.report("", 0, 0, err.Error())
+		return
+	}
+	s.run(string(src), s.env)
+}
+
+func (s *session) resetCmd(string) {
+	s.reset()
+}
+
+func (s *session) time(src string) {
+	start := time.Now()
+	s.run(src, s.env)
+	fmt.Fprintf(s.out, "took %s\n", time.Since(start).Round(time.Microsecond))
+}
@@ -0,0 +1,101 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+)
+
+// enter feeds entries to a fresh session and returns what it printed for
+// the last one.
+func enter(t *testing.T, entries ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	s := newSession(&out)
+	for _, e := range entries {
+		out.Reset()
+		if strings.HasPrefix(e, ":") {
+			s.command(e)
+		} else {
+			s.run(e, s.env)
+		}
+	}
+	return out.String()
+}
+
+func TestCommands(t *testing.T) {
+	input := []struct {
+		entries  []string
+		expected string
+	}{
+		{[]string{":type 1 + 2"}, "INTIGER_TYPE\n"},
+		{[]string{":type fn(x) { x }"}, "FUNCTION\n"},
+		{[]string{":type let x = 1;", "x"}, "x\n^\nerror: identifier not found x\n"},
+		{[]string{"let x = 1;", "let f = fn() { x };", ":env"}, "f  FUNCTION\nx  INTIGER_TYPE\n"},
+		{[]string{"let x = 1;", ":reset", ":env"}, ""},
+		{[]string{":tokens x + 1"}, "1:1  IDENT  \"x\"\n1:3  +      \"+\"\n1:5  INT    \"1\"\n1:6  EOF    \"\"\n"},
+		{[]string{":ast -1 * 2"}, "Program\n  ExpressionStatement\n    InfixExpression *\n      PrefixExpression -\n        IntLiteral 1\n      IntLiteral 2\n"},
+		{[]string{":ast let = 1;"}, "let = 1;\n    ^\nerror: no prefix func for =\n"},
+		{[]string{":nope"}, "error: unknown command :nope, :help lists them\n"},
+		{[]string{":type"}, "usage: :type <code>\n"},
+	}
+	for _, tt := range input {
+		if got := enter(t, tt.entries...); got != tt.expected {
+			t.Errorf("%q: expected\n%s\ngot\n%s", tt.entries, tt.expected, got)
+		}
+	}
+}
+
+func TestASTJSON(t *testing.T) {
+	out := enter(t, ":ast --json 1")
+	if !strings.Contains(out, `"kind": "IntLiteral"`) {
+		t.Fatalf("expected a JSON syntax tree, got\n%s", out)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "double.monkey")
+	if err := os.WriteFile(path, []byte("let double = fn(x) { x * 2 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out := enter(t, ":load "+path, "double(21)"); out != "42\n" {
+		t.Fatalf("expected 42, got %q", out)
+	}
+	if out := enter(t, ":load "+path+".missing"); !strings.HasPrefix(out, "error: ") {
+		t.Fatalf("expected an error, got %q", out)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "double.monkey")
+	if err := os.WriteFile(path, []byte("// double returns twice x.\nlet double = fn(x) { x * 2 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var docs bytes.Buffer
+	defer evaluator.SetOutput(evaluator.SetOutput(&docs))
+	enter(t, ":load "+path, "help(double)")
+	if expected := "fn(x)\n    double returns twice x.\n"; docs.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, docs.String())
+	}
+}
+
+func TestTime(t *testing.T) {
+	out := enter(t, ":time 6 * 7")
+	if !strings.HasPrefix(out, "42\ntook ") {
+		t.Fatalf("expected the value and the time taken, got %q", out)
+	}
+}
+
+func TestMultiLineCommands(t *testing.T) {
+	s := newSession(nil)
+	if !s.incomplete(":ast fn(x) {") {
+		t.Errorf(":ast takes code, which may span lines")
+	}
+	if s.incomplete(":load dir/") {
+		t.Errorf(":load takes a file name, which is never continued")
+	}
+}
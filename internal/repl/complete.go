@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// complete is the prompt's tab completion. It completes the word before the
+// cursor from the keywords, the builtins and the names bound so far, or
+// from the command names at the start of a colon command.
+func (s *session) complete(line string, pos int) (head string, completions []string, tail string) {
+	runes := []rune(line)
+	start := pos
+	for start > 0 && isWordChar(runes[start-1]) {
+		start--
+	}
+	word := string(runes[start:pos])
+	var candidates []string
+	if start == 1 && runes[0] == ':' {
+		start = 0
+		word = ":" + word
+		for _, c := range commands {
+			candidates = append(candidates, ":"+c.name)
+		}
+	} else {
+		if word == "" {
+			return line, nil, ""
+		}
+		for keyword := range token.Keywords {
+			candidates = append(candidates, keyword)
+		}
+		candidates = append(candidates, evaluator.Builtins()...)
+		candidates = append(candidates, s.env.Names()...)
+		candidates = append(candidates, s.macroEnv.Names()...)
+	}
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) && !seen[c] {
+			seen[c] = true
+			completions = append(completions, c)
+		}
+	}
+	sort.Strings(completions)
+	return string(runes[:start]), completions, string(runes[pos:])
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,35 @@
+package repl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	s := newSession(nil)
+	s.run("let price = 3; let print = fn(x) { x };", s.env)
+	input := []struct {
+		line     string
+		pos      int
+		head     string
+		expected []string
+		tail     string
+	}{
+		{"pr", 2, "", []string{"price", "print"}, ""},
+		{"pu", 2, "", []string{"puts"}, ""},
+		{"1 + pri * 2", 7, "1 + ", []string{"price", "print"}, " * 2"},
+		{"re", 2, "", []string{"return"}, ""},
+		{"let", 3, "", []string{"let"}, ""},
+		{":lo", 3, "", []string{":load"}, ""},
+		{":t", 2, "", []string{":time", ":tokens", ":type"}, ""},
+		{"x + ", 4, "x + ", nil, ""},
+		{"zz", 2, "", nil, ""},
+	}
+	for _, tt := range input {
+		head, completions, tail := s.complete(tt.line, tt.pos)
+		if head != tt.head || tail != tt.tail || !reflect.DeepEqual(completions, tt.expected) {
+			t.Errorf("complete(%q, %d) = %q, %q, %q, expected %q, %q, %q",
+				tt.line, tt.pos, head, completions, tail, tt.head, tt.expected, tt.tail)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/peterh/liner"
+)
+
+// A lineReader reads the entries typed at the prompt. liner.State is one;
+// editor is the other.
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(line string)
+	ReadHistory(r io.Reader) (int, error)
+	WriteHistory(w io.Writer) (int, error)
+	Close() error
+}
+
+// historyLimit is how many entries an editor remembers, as many as liner
+// does.
+const historyLimit = 1000
+
+// editor is a line editor that colors the line being typed as it changes,
+// which liner has no way to do. It has the keys of liner and readline
+// most people use: moving and deleting by character and word, Home and
+// End, Ctrl-K and Ctrl-U, history on Up and Down, and Tab completion.
+// Ctrl-C aborts the line with liner.ErrPromptAborted, so the caller can
+// treat both the same, and Ctrl-D on an empty line is io.EOF.
+//
+// A line longer than the terminal is wide wraps onto the rows below, and
+// is redrawn from its first row on every change.
+type editor struct {
+	in    *bufio.Reader
+	out   io.Writer
+	color bool
+	// complete is the word completer, like liner's
+	complete func(line string, pos int) (head string, completions []string, tail string)
+	// width returns the number of columns of the terminal, 0 if unknown
+	width func() int
+	// raw puts the terminal in raw mode while a prompt is read and
+	// returns how to undo it; nil if there is no terminal to set up
+	raw     func() (restore func(), err error)
+	history []string
+
+	// the line being edited
+	prompt string
+	buf    []rune
+	pos    int
+	// hist is the entry of history shown, len(history) for the new line,
+	// which saved holds while the history is shown
+	hist  int
+	saved []rune
+	// rows is the most rows the line has taken since it was first drawn,
+	// 0 if it hasn't been yet, and cursor the column, counted from the
+	// start of the prompt, the cursor was last left at
+	rows   int
+	cursor int
+}
+
+// newEditor returns an editor for the terminal of the standard input and
+// output, or nil if they aren't both terminals that can be put in raw
+// mode.
+func newEditor(s *session) *editor {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !isTerminal(in) || !isTerminal(out) {
+		return nil
+	}
+	restore, err := makeRaw(in)
+	if err != nil {
+		return nil
+	}
+	restore()
+	return &editor{
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		color:    s.color,
+		complete: s.complete,
+		width:    func() int { return termWidth(out) },
+		raw:      func() (func(), error) { return makeRaw(in) },
+	}
+}
+
+func ctrl(key rune) rune {
+	return key & 0x1f
+}
+
+// Prompt shows prompt and returns the line typed after it.
+func (e *editor) Prompt(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	e.hist, e.saved = len(e.history), nil
+	e.rows, e.cursor = 0, 0
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			e.end("\r\n")
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			e.end("\r\n")
+			return string(e.buf), nil
+		case ctrl('C'):
+			e.end("^C\r\n")
+			return "", liner.ErrPromptAborted
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				e.end("\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case 127, ctrl('H'):
+			if e.pos > 0 {
+				e.delete(e.pos-1, e.pos)
+			}
+		case ctrl('A'):
+			e.pos = 0
+		case ctrl('E'):
+			e.pos = len(e.buf)
+		case ctrl('B'):
+			e.pos = max(e.pos-1, 0)
+		case ctrl('F'):
+			e.pos = min(e.pos+1, len(e.buf))
+		case ctrl('K'):
+			e.buf = e.buf[:e.pos]
+		case ctrl('U'):
+			e.delete(0, e.pos)
+		case ctrl('W'):
+			e.delete(e.wordStart(), e.pos)
+		case ctrl('L'):
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+			e.rows, e.cursor = 0, 0
+		case ctrl('P'):
+			e.browse(-1)
+		case ctrl('N'):
+			e.browse(1)
+		case '\t':
+			e.tab()
+		case 0x1b:
+			e.escape()
+		default:
+			if unicode.IsPrint(r) {
+				e.buf = append(e.buf[:e.pos], append([]rune{r}, e.buf[e.pos:]...)...)
+				e.pos++
+			}
+		}
+		e.refresh()
+	}
+}
+
+// escape handles the keys that send escape sequences: the arrows, Home,
+// End and Delete, and Alt-b and Alt-f for moving by word.
+func (e *editor) escape() {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return
+	}
+	switch r {
+	case 'b':
+		e.pos = e.wordStart()
+		return
+	case 'f':
+		e.pos = e.wordEnd()
+		return
+	case '[', 'O':
+	default:
+		return
+	}
+	// the parameters of a CSI sequence, like the 3 of Delete's ESC [ 3 ~
+	var param strings.Builder
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return
+		}
+		if r < '0' || r > '9' && r != ';' {
+			break
+		}
+		param.WriteRune(r)
+	}
+	switch r {
+	case 'A':
+		e.browse(-1)
+	case 'B':
+		e.browse(1)
+	case 'C':
+		e.pos = min(e.pos+1, len(e.buf))
+	case 'D':
+		e.pos = max(e.pos-1, 0)
+	case 'H':
+		e.pos = 0
+	case 'F':
+		e.pos = len(e.buf)
+	case '~':
+		switch param.String() {
+		case "1", "7":
+			e.pos = 0
+		case "4", "8":
+			e.pos = len(e.buf)
+		case "3":
+			e.delete(e.pos, e.pos+1)
+		}
+	}
+}
+
+// delete removes the characters from i up to j, and leaves the cursor
+// at i.
+func (e *editor) delete(i, j int) {
+	j = min(j, len(e.buf))
+	if i >= j {
+		return
+	}
+	e.buf = append(e.buf[:i], e.buf[j:]...)
+	e.pos = i
+}
+
+// wordStart returns where the word before the cursor starts, skipping
+// the spaces and punctuation right before it.
+func (e *editor) wordStart() int {
+	i := e.pos
+	for i > 0 && !isWordChar(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && isWordChar(e.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd returns where the word after the cursor ends.
+func (e *editor) wordEnd() int {
+	i := e.pos
+	for i < len(e.buf) && !isWordChar(e.buf[i]) {
+		i++
+	}
+	for i < len(e.buf) && isWordChar(e.buf[i]) {
+		i++
+	}
+	return i
+}
+
+// browse shows the history entry delta entries away from the one shown,
+// going back to the line being typed past the newest.
+func (e *editor) browse(delta int) {
+	hist := e.hist + delta
+	if hist < 0 || hist > len(e.history) {
+		return
+	}
+	if e.hist == len(e.history) {
+		e.saved = append([]rune(nil), e.buf...)
+	}
+	e.hist = hist
+	if hist == len(e.history) {
+		e.buf = e.saved
+	} else {
+		e.buf = []rune(e.history[hist])
+	}
+	e.pos = len(e.buf)
+}
+
+// tab completes the word before the cursor as far as its completions
+// agree, and lists them below the line if that's no further.
+func (e *editor) tab() {
+	if e.complete == nil {
+		return
+	}
+	head, completions, tail := e.complete(string(e.buf), e.pos)
+	if len(completions) == 0 {
+		return
+	}
+	// the common prefix is trimmed by rune, since names may have
+	// characters of more than a byte
+	prefix := []rune(completions[0])
+	for _, c := range completions[1:] {
+		for !strings.HasPrefix(c, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	typed := e.pos - len([]rune(head))
+	if len(completions) == 1 || len(prefix) > typed {
+		e.buf = []rune(head + string(prefix) + tail)
+		e.pos = len([]rune(head)) + len(prefix)
+		return
+	}
+	pos := e.pos
+	e.end("\r\n" + strings.Join(completions, "  ") + "\r\n")
+	e.pos = pos
+}
+
+// end moves the cursor past the end of the line and writes s, after which
+// the line is drawn afresh.
+func (e *editor) end(s string) {
+	e.pos = len(e.buf)
+	e.refresh()
+	io.WriteString(e.out, s)
+	e.rows, e.cursor = 0, 0
+}
+
+// refresh draws the prompt and the line over what was drawn before, and
+// puts the cursor where it is in the line.
+func (e *editor) refresh() {
+	cols := 0
+	if e.width != nil {
+		cols = e.width()
+	}
+	if cols <= 0 {
+		cols = 80
+	}
+	plen := runewidth.StringWidth(e.prompt)
+	var out strings.Builder
+	if e.rows > 0 {
+		// go down to the last row drawn and clear the rows up from it
+		if down := e.rows - (e.cursor+cols)/cols; down > 0 {
+			fmt.Fprintf(&out, "\x1b[%dB", down)
+		}
+		for i := 1; i < e.rows; i++ {
+			out.WriteString("\r\x1b[0K\x1b[1A")
+		}
+	}
+	out.WriteString("\r\x1b[0K")
+	out.WriteString(e.prompt)
+	if e.color {
+		out.WriteString(highlight(string(e.buf)))
+	} else {
+		out.WriteString(string(e.buf))
+	}
+
+	width := plen + runewidth.StringWidth(string(e.buf))
+	rows := max((width+cols-1)/cols, 1)
+	cursor := plen + runewidth.StringWidth(string(e.buf[:e.pos]))
+	if e.pos == len(e.buf) && e.pos > 0 && cursor%cols == 0 {
+		// the terminal leaves the cursor on the last column rather than
+		// starting a row for it
+		out.WriteString("\n\r")
+		rows++
+	}
+	e.rows = max(e.rows, rows)
+	// the rows are counted from 1, and the cursor is on row
+	// (cursor+cols)/cols
+	if up := rows - (cursor+cols)/cols; up > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", up)
+	}
+	out.WriteString("\r")
+	if col := cursor % cols; col > 0 {
+		fmt.Fprintf(&out, "\x1b[%dC", col)
+	}
+	e.cursor = cursor
+	io.WriteString(e.out, out.String())
+}
+
+// AppendHistory adds line to the history, unless it repeats the last
+// entry.
+func (e *editor) AppendHistory(line string) {
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > historyLimit {
+		e.history = e.history[len(e.history)-historyLimit:]
+	}
+}
+
+// ReadHistory adds the lines of r to the history, in liner's format: one
+// entry a line.
+func (e *editor) ReadHistory(r io.Reader) (int, error) {
+	n := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e.AppendHistory(scanner.Text())
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// WriteHistory writes the history to w in the format ReadHistory reads.
+func (e *editor) WriteHistory(w io.Writer) (int, error) {
+	for i, line := range e.history {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return i, err
+		}
+	}
+	return len(e.history), nil
+}
+
+// Close does nothing: the terminal is only in raw mode during a Prompt.
+func (e *editor) Close() error {
+	return nil
+}
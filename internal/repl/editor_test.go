@@ -0,0 +1,156 @@
+package repl
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/peterh/liner"
+)
+
+const (
+	keyUp    = "\x1b[A"
+	keyDown  = "\x1b[B"
+	keyLeft  = "\x1b[D"
+	keyHome  = "\x1b[H"
+	keyDel   = "\x1b[3~"
+	keyBack  = "\x7f"
+	keyEnter = "\r"
+)
+
+func newTestEditor(keys string, out io.Writer) *editor {
+	return &editor{
+		in:    bufio.NewReader(strings.NewReader(keys)),
+		out:   out,
+		width: func() int { return 20 },
+		complete: func(line string, pos int) (string, []string, string) {
+			return newSession(nil).complete(line, pos)
+		},
+	}
+}
+
+func TestEditorKeys(t *testing.T) {
+	input := []struct {
+		keys     string
+		expected string
+	}{
+		{"let x = 1;" + keyEnter, "let x = 1;"},
+		{"ab" + keyLeft + "c" + keyEnter, "acb"},
+		{"abc" + keyBack + keyEnter, "ab"},
+		{"abc" + keyHome + keyDel + keyEnter, "bc"},
+		{"abc\x02\x02\x04" + keyEnter, "ac"},
+		{"bc\x01a\x05d" + keyEnter, "abcd"},
+		{"let foo = bar\x17baz" + keyEnter, "let foo = baz"},
+		{"abc\x02\x0b" + keyEnter, "ab"},
+		{"abc\x02\x15" + keyEnter, "c"},
+		{"one two\x1bbx" + keyEnter, "one xtwo"},
+		{"pu\t(1)" + keyEnter, "puts(1)"},
+		{"assert\t" + keyEnter, "assert"},
+		{"1 + 1\n", "1 + 1"},
+	}
+	for _, tt := range input {
+		var out strings.Builder
+		got, err := newTestEditor(tt.keys, &out).Prompt(">>> ")
+		if err != nil || got != tt.expected {
+			t.Errorf("%q: expected %q got %q (%v)", tt.keys, tt.expected, got, err)
+		}
+	}
+}
+
+func TestEditorCompleteNonASCII(t *testing.T) {
+	s := newSession(nil)
+	s.run("let xé = 1; let xè = 2; let πa1 = 3; let πa2 = 4;", s.env)
+	input := []struct {
+		keys     string
+		expected string
+	}{
+		// xé and xè share only the x, whatever their bytes have in common
+		{"x\t" + keyEnter, "x"},
+		{"xé\t + 1" + keyEnter, "xé + 1"},
+		{"π\t" + keyEnter, "πa"},
+		{"1 + π\t2" + keyEnter, "1 + πa2"},
+	}
+	for _, tt := range input {
+		var out strings.Builder
+		e := newTestEditor(tt.keys, &out)
+		e.complete = s.complete
+		got, err := e.Prompt(">>> ")
+		if err != nil || got != tt.expected {
+			t.Errorf("%q: expected %q got %q (%v)", tt.keys, tt.expected, got, err)
+		}
+	}
+}
+
+func TestEditorAbort(t *testing.T) {
+	var out strings.Builder
+	if _, err := newTestEditor("abc\x03", &out).Prompt(">>> "); err != liner.ErrPromptAborted {
+		t.Errorf("expected Ctrl-C to abort, got %v", err)
+	}
+	if _, err := newTestEditor("\x04", &out).Prompt(">>> "); err != io.EOF {
+		t.Errorf("expected Ctrl-D on an empty line to be io.EOF, got %v", err)
+	}
+	if _, err := newTestEditor("abc", &out).Prompt(">>> "); err != io.EOF {
+		t.Errorf("expected the end of the input to be io.EOF, got %v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	var out strings.Builder
+	e := newTestEditor(keyUp+keyUp+keyEnter+"new"+keyUp+keyDown+keyEnter+keyUp+keyUp+keyUp+keyEnter, &out)
+	n, err := e.ReadHistory(strings.NewReader("1 + 1\n2 + 2\n2 + 2\n"))
+	if n != 3 || err != nil {
+		t.Fatalf("expected to read 3 lines, got %d (%v)", n, err)
+	}
+	// Up and Down walk the history, and back to the line being typed
+	for _, expected := range []string{"1 + 1", "new", "2 + 2"} {
+		got, err := e.Prompt(">>> ")
+		if err != nil || got != expected {
+			t.Errorf("expected %q got %q (%v)", expected, got, err)
+		}
+		e.AppendHistory(got)
+	}
+	var saved strings.Builder
+	e.WriteHistory(&saved)
+	if expected := "1 + 1\n2 + 2\n1 + 1\nnew\n2 + 2\n"; saved.String() != expected {
+		t.Errorf("expected the history %q got %q", expected, saved.String())
+	}
+}
+
+func TestEditorHighlight(t *testing.T) {
+	var out strings.Builder
+	e := newTestEditor("let x = true", &out)
+	e.color = true
+	e.Prompt(">>> ")
+	// every key redraws the line, colored as far as it has been typed
+	for _, expected := range []string{
+		"\r\x1b[0K>>> l",
+		"\r\x1b[0K>>> " + colorMagenta + "let" + colorReset,
+		colorMagenta + "let" + colorReset + " x " + colorYellow + "=" + colorReset + " " + colorCyan + "true" + colorReset,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output to contain %q, got %q", expected, out.String())
+		}
+	}
+}
+
+func TestEditorWrap(t *testing.T) {
+	var out strings.Builder
+	// 4 columns of prompt and 20 of input take two rows of 20 columns
+	e := newTestEditor(strings.Repeat("x", 20)+keyHome, &out)
+	e.Prompt(">>> ")
+	for _, expected := range []string{
+		// a line that fills the row exactly starts the next one for the
+		// cursor
+		">>> " + strings.Repeat("x", 16) + "\n\r",
+		// the rows below the first are cleared before the line is drawn
+		// again
+		"\r\x1b[0K\x1b[1A\r\x1b[0K>>> " + strings.Repeat("x", 17) + "\r\x1b[1C",
+		// Home puts the cursor on the first row, after the prompt
+		">>> " + strings.Repeat("x", 20) + "\x1b[1A\r\x1b[4C",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output to contain %q, got %q", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// ANSI escape sequences for the colors the prompt uses.
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// useColor reports whether output to f should be colored: f has to be a
+// terminal and NO_COLOR unset or empty, see https://no-color.org.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorOf returns the color tokens of type t are shown in, or "" for
+// those left alone.
+func colorOf(t token.TokenType) string {
+	switch t {
+	case token.INT, token.TRUE, token.FALSE:
+		return colorCyan
+	case token.COMMENT:
+		return colorGray
+	case token.ILLEGAL:
+		return colorRed
+	case token.IDENT, token.EOF, token.COMMA, token.SEMICOLON,
+		token.LPAREN, token.RPAREN, token.LBRACE, token.RBRACE,
+		token.LBRACKET, token.RBRACKET:
+		return ""
+	}
+	for _, kind := range token.Keywords {
+		if token.TokenType(kind) == t {
+			return colorMagenta
+		}
+	}
+	return colorYellow
+}
+
+// highlight returns src with its keywords, literals, operators and
+// comments colored. Everything between the tokens is kept as it is.
+func highlight(src string) string {
+	runes := []rune(src)
+	// lineStart[i] is the offset in runes of line i+1
+	lineStart := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			lineStart = append(lineStart, i+1)
+		}
+	}
+	l := lexer.New(src)
+	l.EmitComments(true)
+	var out strings.Builder
+	pos := 0
+	for _, t := range l.Tokens() {
+		if t.Type == token.EOF {
+			break
+		}
+		start := lineStart[t.Line-1] + t.Column - 1
+		end := start + len([]rune(t.Literal))
+		out.WriteString(string(runes[pos:start]))
+		if c := colorOf(t.Type); c != "" {
+			fmt.Fprintf(&out, "%s%s%s", c, string(runes[start:end]), colorReset)
+		} else {
+			out.WriteString(string(runes[start:end]))
+		}
+		pos = end
+	}
+	out.WriteString(string(runes[pos:]))
+	return out.String()
+}
+
+// report prints msg as an error. If line is above 0 it first shows that
+// line of src with a caret under column.
+func (s *session) report(src string, line, column int, msg string) {
+	lines := strings.Split(src, "\n")
+	if line > 0 && line <= len(lines) {
+		text := lines[line-1]
+		// keep the tabs before the caret so it lines up under them
+		var pad strings.Builder
+		for i, r := range []rune(text) {
+			if i >= column-1 {
+				break
+			}
+			if r == '\t' {
+				pad.WriteRune('\t')
+			} else {
+				pad.WriteRune(' ')
+			}
+		}
+		if s.color {
+			fmt.Fprintf(s.out, "%s\n%s%s^%s\n", highlight(text), pad.String(), colorBoldRed, colorReset)
+		} else {
+			fmt.Fprintf(s.out, "%s\n%s^\n", text, pad.String())
+		}
+	}
+	if s.color {
+		fmt.Fprintf(s.out, "%serror:%s %s\n", colorBoldRed, colorReset, msg)
+	} else {
+		fmt.Fprintf(s.out, "error: %s\n", msg)
+	}
+}
+
+// reportParseError prints a message from the parser, which starts with
+// "line:column: " when the parser knows where the problem is.
+func (s *session) reportParseError(src, msg string) {
+	msg = strings.TrimSpace(msg)
+	var line, column int
+	if n, _ := fmt.Sscanf(msg, "%d:%d:", &line, &column); n == 2 {
+		_, rest, _ := strings.Cut(msg, ": ")
+		s.report(src, line, column, rest)
+		return
+	}
+	s.report(src, 0, 0, msg)
+}
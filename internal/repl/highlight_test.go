@@ -0,0 +1,58 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 10;", "\x1b[35mlet\x1b[0m x \x1b[33m=\x1b[0m \x1b[36m10\x1b[0m;"},
+		{"f(true)  // ok", "f(\x1b[36mtrue\x1b[0m)  \x1b[90m// ok\x1b[0m"},
+		{"émoji >= 0x1F", "émoji \x1b[33m>=\x1b[0m \x1b[36m0x1F\x1b[0m"},
+		{"if (a) {\n\treturn 1\n}", "\x1b[35mif\x1b[0m (a) {\n\t\x1b[35mreturn\x1b[0m \x1b[36m1\x1b[0m\n}"},
+		{"1 ? 2", "\x1b[36m1\x1b[0m \x1b[31m?\x1b[0m \x1b[36m2\x1b[0m"},
+	}
+	for _, tt := range input {
+		if got := highlight(tt.input); got != tt.expected {
+			t.Errorf("highlight(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	s := newSession(&out)
+	s.report("let f = fn() {\n\tx + 1\n};", 2, 2, "identifier not found x")
+	expected := "\tx + 1\n\t^\nerror: identifier not found x\n"
+	if out.String() != expected {
+		t.Fatalf("expected %q got %q", expected, out.String())
+	}
+
+	out.Reset()
+	s.color = true
+	s.report("y", 1, 1, "identifier not found y")
+	expected = "y\n\x1b[1;31m^\x1b[0m\n\x1b[1;31merror:\x1b[0m identifier not found y\n"
+	if out.String() != expected {
+		t.Fatalf("expected %q got %q", expected, out.String())
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if useColor(f) {
+		t.Errorf("expected no color for a file")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if useColor(os.Stdout) {
+		t.Errorf("expected no color with NO_COLOR set")
+	}
+}
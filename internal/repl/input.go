@@ -0,0 +1,60 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// continues holds the tokens that cannot end an entry: whatever follows
+// them is still to be typed.
+var continues = map[token.TokenType]bool{
+	token.ASSIGN:         true,
+	token.PLUS:           true,
+	token.MINUS:          true,
+	token.MULTIPLICATION: true,
+	token.DIVISION:       true,
+	token.LT:             true,
+	token.GT:             true,
+	token.LTOREQ:         true,
+	token.GTOREQ:         true,
+	token.EQ:             true,
+	token.NOT_EQ:         true,
+	token.BANG:           true,
+	token.COMMA:          true,
+	token.LET:            true,
+	token.RETURN:         true,
+	token.FUNCTION:       true,
+	token.MACRO:          true,
+	token.IF:             true,
+	token.ELSE:           true,
+}
+
+// incomplete reports whether src is the start of an entry rather than a
+// whole one: it has an unclosed '(', '{', '[' or block comment, or ends
+// with a token that needs something after it. Input with more closing
+// brackets than opening ones is complete, so the parser gets to complain.
+func incomplete(src string) bool {
+	depth := 0
+	last := token.Token{Type: token.EOF}
+	for _, t := range lexer.New(src).Tokens() {
+		switch t.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+			if depth < 0 {
+				return false
+			}
+		case token.ILLEGAL:
+			if strings.HasPrefix(t.Literal, "/*") {
+				return true
+			}
+		case token.EOF:
+			continue
+		}
+		last = t
+	}
+	return depth > 0 || continues[last.Type]
+}
@@ -0,0 +1,34 @@
+package repl
+
+import "testing"
+
+func TestIncomplete(t *testing.T) {
+	input := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", false},
+		{"5 + 5", false},
+		{"", false},
+		{"let f = fn(x) {", true},
+		{"let f = fn(x) {\n    x * 2\n", true},
+		{"let f = fn(x) {\n    x * 2\n};", false},
+		{"add(1,", true},
+		{"add(1,\n2)", false},
+		{"[1, 2", true},
+		{"let x =", true},
+		{"1 +", true},
+		{"if (x > 1) { x } else", true},
+		{"return", true},
+		{"/* a comment", true},
+		{"/* a comment */", false},
+		{"// a comment {", false},
+		{"})", false},
+		{"x }", false},
+	}
+	for _, tt := range input {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package repl implements the interactive Monkey prompt.
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/resolver"
+	"github.com/peterh/liner"
+)
+
+
+// session is what the prompt remembers between entries.
+type session struct{
+    env      *object.Enviroment
+    macroEnv *object.Enviroment
+    out      io.Writer
+    // color is set if out is a terminal that should get colors
+    color    bool
+}
+
+func newSession(out io.Writer) *session{
+    s := &session{out: out}
+    s.reset()
+    return s
+}
+
+// reset forgets every binding and macro.
+func (s *session) reset(){
+    s.env = object.NewEnviroment()
+    s.macroEnv = object.NewEnviroment()
+}
+
+// Start runs the prompt until the user quits.
+func  Start() {
+    s := newSession(os.Stdout)
+    s.color = useColor(os.Stdout)
+    // the editor colors the line as it is typed; without colors, or a
+    // terminal it knows how to drive, liner does the job
+    var l lineReader
+    if e := newEditor(s); e != nil && s.color{
+        l = e
+    } else {
+        state := liner.NewLiner()
+        state.SetCtrlCAborts(true)
+        state.SetWordCompleter(s.complete)
+        l = state
+    }
+    defer l.Close()
+    his, err := historyPath()
+    if err == nil{
+        if f, err := os.Open(his); err == nil{
+            l.ReadHistory(f)
+            f.Close()
+        }
+        defer saveHistory(l, his)
+    }
+
+    // lines holds the entry typed so far, while it is incomplete
+    var lines []string
+    for{
+        prompt := ">>> "
+        if len(lines) > 0{
+            prompt = "... "
+        }
+        input, err := l.Prompt(prompt)
+        if err != nil{
+            if err == liner.ErrPromptAborted{
+                if len(lines) > 0{
+                    // Ctrl-C drops the entry being typed
+                    lines = nil
+                    continue
+                }
+                fmt.Println("byeeee")
+                break
+            }
+            if err != io.EOF{
+                fmt.Println("error:", err)
+            }
+            break
+        }
+        if len(lines) == 0{
+            if strings.TrimSpace(input) == ""{
+                continue
+            }
+            if input == "exit" || input == "quit" {
+                break
+            }
+        }
+        l.AppendHistory(input)
+        lines = append(lines, input)
+        src := strings.Join(lines, "\n")
+        if s.incomplete(src){
+            continue
+        }
+        lines = nil
+        if strings.HasPrefix(src, ":"){
+            s.command(src)
+            continue
+        }
+        s.run(src, s.env)
+    }
+}
+
+// historyPath returns where the prompt keeps its history:
+// $XDG_STATE_HOME/monkey/history, with XDG_STATE_HOME defaulting to
+// ~/.local/state as the XDG base directory spec says.
+func historyPath() (string, error){
+    dir := os.Getenv("XDG_STATE_HOME")
+    if !filepath.IsAbs(dir){
+        home, err := os.UserHomeDir()
+        if err != nil{
+            return "", err
+        }
+        dir = filepath.Join(home, ".local", "state")
+    }
+    return filepath.Join(dir, "monkey", "history"), nil
+}
+
+// saveHistory writes the history of l to path. Failing to is not worth
+// more than a warning on the way out.
+func saveHistory(l lineReader, path string){
+    err := os.MkdirAll(filepath.Dir(path), 0o700)
+    if err == nil{
+        var f *os.File
+        f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+        if err == nil{
+            _, err = l.WriteHistory(f)
+            if cerr := f.Close(); err == nil{
+                err = cerr
+            }
+        }
+    }
+    if err != nil{
+        fmt.Fprintln(os.Stderr, "monkey: saving the history:", err)
+    }
+}
+
+// incomplete reports whether src needs more lines. Only commands that take
+// code can go on past their first line.
+func (s *session) incomplete(src string) bool{
+    if strings.HasPrefix(src, ":"){
+        c, arg := lookup(src)
+        if c == nil || !c.code{
+            return false
+        }
+        src = arg
+    }
+    return incomplete(src)
+}
+
+// parse parses src, printing the syntax errors if there are any. The
+// comments are kept, so help() can show the doc comments of functions.
+func (s *session) parse(src string) (*ast.Program, bool){
+    l := lexer.New(src)
+    l.EmitComments(true)
+    p := parser.New(l)
+    program := p.ParseProgram()
+    if len(p.Errors()) != 0{
+        for _, msg := range p.Errors(){
+            s.reportParseError(src, msg)
+        }
+        return nil, false
+    }
+    return program, true
+}
+
+// eval expands the macros in src and evaluates it in env. It returns false
+// if src didn't get as far as running.
+func (s *session) eval(src string, env *object.Enviroment) (object.Object, bool){
+    program, ok := s.parse(src)
+    if !ok{
+        return nil, false
+    }
+    evaluator.DefineMacros(program, s.macroEnv)
+    expanded, err := evaluator.ExpandMacros(program, s.macroEnv)
+    if err != nil{
+        s.reportParseError(src, err.Error())
+        return nil, false
+    }
+    // a function entered here may use a name that a later entry binds, so
+    // unbound names aren't reported until they are reached
+    resolver.Resolve(expanded, env)
+    return evaluator.Eval(expanded, env), true
+}
+
+// run evaluates one entry in env and prints its value.
+func (s *session) run(src string, env *object.Enviroment) {
+    e, _ := s.eval(src, env)
+    if err, ok := e.(*object.Error); ok{
+        s.report(src, err.Line, err.Column, err.Message)
+        return
+    }
+    if e != nil{
+        fmt.Fprintln(s.out, e.Inspect())
+    }
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package repl
+
+import "errors"
+
+// makeRaw isn't supported here, so the prompt is left to liner.
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("raw mode isn't supported on this system")
+}
+
+func isTerminal(fd int) bool { return false }
+
+func termWidth(fd int) int { return 0 }
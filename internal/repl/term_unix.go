@@ -0,0 +1,54 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package repl
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw puts the terminal fd is open on in raw mode, where keys are read
+// one at a time, unechoed, and Ctrl-C is a key rather than a signal. It
+// returns the function that puts the terminal back the way it was.
+func makeRaw(fd int) (restore func(), err error) {
+	var old syscall.Termios
+	if err := ioctl(fd, getTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Iflag &^= syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON | syscall.BRKINT
+	raw.Cflag |= syscall.CS8
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, setTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return func() { ioctl(fd, setTermios, unsafe.Pointer(&old)) }, nil
+}
+
+// isTerminal reports whether fd is open on a terminal.
+func isTerminal(fd int) bool {
+	var t syscall.Termios
+	return ioctl(fd, getTermios, unsafe.Pointer(&t)) == nil
+}
+
+// termWidth returns the number of columns of the terminal fd is open on,
+// or 0 if it can't tell.
+func termWidth(fd int) int {
+	var size struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	if ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&size)) != nil {
+		return 0
+	}
+	return int(size.cols)
+}
@@ -0,0 +1,116 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/format"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/token"
+)
+
+// workspace returns the session's bindings as a Monkey script that makes
+// them again. Values that can't be written as source, like closures over
+// a function's scope, are left out and their names returned in skipped.
+func (s *session) workspace() (src []byte, skipped []string, err error) {
+	var out strings.Builder
+	// the blank line keeps this from documenting the first function
+	out.WriteString("// saved by :save in the monkey prompt, :restore reads it back\n\n")
+	write := func(name string, obj object.Object) error {
+		var value, doc string
+		switch obj := obj.(type) {
+		case *object.Integer, *object.Boolean:
+			value = obj.Inspect()
+		case *object.Builtin:
+			value = obj.Name
+		case *object.Function:
+			if obj.Env != s.env {
+				skipped = append(skipped, name)
+				return nil
+			}
+			text, err := format.Node(&ast.FunctionLiteral{
+				Token:  &token.Token{Type: token.FUNCTION, Literal: "fn"},
+				Params: obj.Params,
+				Result: obj.Result,
+				Body:   obj.Body,
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			value, doc = string(text), obj.Doc
+		case *object.Macro:
+			text, err := format.Node(&ast.MacroLiteral{
+				Token:  &token.Token{Type: token.MACRO, Literal: "macro"},
+				Params: obj.Params,
+				Body:   obj.Body,
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			value = string(text)
+		default:
+			skipped = append(skipped, name)
+			return nil
+		}
+		// the doc comment goes right above the let, where help finds it
+		// once the file is restored
+		if doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				out.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+			}
+		}
+		fmt.Fprintf(&out, "let %s = %s;\n", name, value)
+		return nil
+	}
+	for _, name := range s.macroEnv.Names() {
+		obj, _ := s.macroEnv.Get(name)
+		if err := write(name, obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, name := range s.env.Names() {
+		obj, _ := s.env.Get(name)
+		if err := write(name, obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	return []byte(out.String()), skipped, nil
+}
+
+func (s *session) save(path string) {
+	src, skipped, err := s.workspace()
+	if err == nil {
+		err = os.WriteFile(path, src, 0o644)
+	}
+	if err != nil {
+		s.report("", 0, 0, err.Error())
+		return
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(s.out, "not saved, they can't be written as source: %s\n", strings.Join(skipped, ", "))
+	}
+}
+
+// restore replaces the session's bindings with those saved in path. The
+// file is run in a session of its own first, so the bindings are kept if
+// it fails.
+func (s *session) restore(path string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		s.report("", 0, 0, err.Error())
+		return
+	}
+	fresh := &session{out: s.out, color: s.color}
+	fresh.reset()
+	obj, ok := fresh.eval(string(src), fresh.env)
+	if !ok {
+		return
+	}
+	if err, ok := obj.(*object.Error); ok {
+		s.report(string(src), err.Line, err.Column, err.Message)
+		return
+	}
+	s.env, s.macroEnv = fresh.env, fresh.macroEnv
+}
@@ -0,0 +1,124 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+)
+
+func TestSaveRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.monkey")
+	var out bytes.Buffer
+	s := newSession(&out)
+	s.run(`let n = 0x10; let yes = 1 < 2; let p = puts;
+let double = fn(x) { x * 2 };
+let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) };
+let adder = fn(x) { fn(y) { x + y } }; let addTwo = adder(2);`, s.env)
+	s.command(":save " + path)
+	if expected := "not saved, they can't be written as source: addTwo\n"; out.String() != expected {
+		t.Fatalf("expected %q got %q", expected, out.String())
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// saved by :save in the monkey prompt, :restore reads it back
+
+let unless = macro(c, a, b) {
+    quote(if (!unquote(c)) {
+        unquote(a);
+    } else {
+        unquote(b);
+    });
+};
+let adder = fn(x) {
+    fn(y) {
+        x + y;
+    };
+};
+let double = fn(x) {
+    x * 2;
+};
+let n = 16;
+let p = puts;
+let yes = true;
+`
+	if string(saved) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, saved)
+	}
+
+	out.Reset()
+	r := newSession(&out)
+	r.run("let stale = 1;", r.env)
+	r.command(":restore " + path)
+	r.run("unless(yes, 0, double(n) + adder(1)(1))", r.env)
+	r.run("stale", r.env)
+	if expected := "34\nstale\n^\nerror: identifier not found stale\n"; out.String() != expected {
+		t.Fatalf("expected %q got %q", expected, out.String())
+	}
+}
+
+func TestSaveRestoreDocs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.monkey")
+	var out bytes.Buffer
+	s := newSession(&out)
+	s.run("// add returns the sum.\n//\n// Both must be ints.\nlet add = fn(a: int, b: int) -> int { a + b };", s.env)
+	s.run("let first = fn() { 1 };", s.env)
+	s.command(":save " + path)
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "// add returns the sum.\n//\n// Both must be ints.\nlet add = fn(a: int, b: int) -> int {"; !strings.Contains(string(saved), expected) {
+		t.Fatalf("expected the doc and annotations to be saved, got\n%s", saved)
+	}
+
+	var docs bytes.Buffer
+	defer evaluator.SetOutput(evaluator.SetOutput(&docs))
+	r := newSession(&out)
+	r.command(":restore " + path)
+	r.run("help(add)", r.env)
+	r.run("help(first)", r.env)
+	if expected := "fn(a, b)\n    add returns the sum.\n\n    Both must be ints.\nfn()\n"; docs.String() != expected {
+		t.Fatalf("expected %q got %q", expected, docs.String())
+	}
+}
+
+func TestRestoreFails(t *testing.T) {
+	dir := t.TempDir()
+	for _, src := range []string{"let a = 1; let b = ;", "let a = 1; let b = nope;"} {
+		path := filepath.Join(dir, "session.monkey")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		s := newSession(&out)
+		s.run("let kept = 1; let twice = macro(x) { quote(unquote(x) * 2) };", s.env)
+		s.command(":restore " + path)
+		if !strings.Contains(out.String(), "error:") {
+			t.Errorf("%q: expected the error to be reported, got %q", src, out.String())
+		}
+		out.Reset()
+		s.run("twice(kept)", s.env)
+		s.run("a", s.env)
+		if expected := "2\na\n^\nerror: identifier not found a\n"; out.String() != expected {
+			t.Errorf("%q: expected the bindings to be kept, %q got %q", src, expected, out.String())
+		}
+	}
+}
+
+func TestHistoryPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	if got, _ := historyPath(); got != filepath.Join("/state", "monkey", "history") {
+		t.Errorf("expected the history under XDG_STATE_HOME, got %s", got)
+	}
+	t.Setenv("XDG_STATE_HOME", "relative")
+	t.Setenv("HOME", "/home/someone")
+	if got, _ := historyPath(); got != filepath.Join("/home/someone", ".local", "state", "monkey", "history") {
+		t.Errorf("expected a relative XDG_STATE_HOME to be ignored, got %s", got)
+	}
+}
@@ -0,0 +1,206 @@
+// Package resolver works out, before a program runs, where the value of
+// every name it uses will be.
+//
+// The parameters and lets of each function get numbered slots, and an
+// identifier that refers to one of them is pointed at it with an ast.Ref:
+// the slot, and how many functions out from the identifier's own it is
+// in. The evaluator keeps those bindings in an array per call rather than
+// a map, so looking them up needs no hashing. Names bound at the top level
+// stay globals, looked up by name, since the REPL, the debugger and the
+// tests look them up by name too.
+//
+// Names that nothing binds are reported, so a misspelling fails before
+// the program starts rather than when it gets there.
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+// An Error is an identifier that nothing binds.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Resolve numbers the locals of the functions in program and points the
+// identifiers that refer to them at their slots. The program is going to
+// run in env, whose bindings count as globals along with the lets at the
+// top level of program and the builtins. Macros have to be expanded
+// first.
+//
+// It returns the identifiers nothing binds, in the order they appear; the
+// program is resolved even so, and they fail when they are reached.
+func Resolve(program *ast.Program, env *object.Enviroment) []*Error {
+	r := &resolver{env: env}
+	r.scope = &scope{names: map[string]int{}}
+	locals(program, r.scope)
+	for _, s := range program.Statements {
+		r.node(s)
+	}
+	return r.errors
+}
+
+type resolver struct {
+	env    *object.Enviroment
+	scope  *scope
+	errors []*Error
+}
+
+// A scope is the body of a function, or the top level. Blocks don't have
+// scopes of their own: like the enviroments the evaluator makes, a let in
+// a block binds in the enclosing function.
+type scope struct {
+	outer *scope
+	// names maps the names bound in the scope to their slots; at the top
+	// level the slots don't matter
+	names  map[string]int
+	locals []string
+}
+
+func (s *scope) bind(name string) int {
+	if slot, ok := s.names[name]; ok {
+		// binding a name again in the same function takes the same slot,
+		// so closures see the new value as they would in a map
+		return slot
+	}
+	s.names[name] = len(s.locals)
+	s.locals = append(s.locals, name)
+	return s.names[name]
+}
+
+// locals binds the lets of body in s, without going into the functions in
+// it. All of them are bound before anything is resolved, since a function
+// can use a name bound after it, as long as it is called after that.
+func locals(body ast.Node, s *scope) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			if n.Name != nil {
+				s.bind(n.Name.Value)
+			}
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			return n == body
+		case *ast.Call:
+			return !isQuote(n)
+		}
+		return true
+	})
+}
+
+func isQuote(call *ast.Call) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == "quote" && len(call.Arguments) == 1
+}
+
+func (r *resolver) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.LetStatement:
+		r.node(n.Value)
+		if n.Name != nil && r.scope.outer != nil {
+			n.Name.Ref = &ast.Ref{Depth: 0, Slot: r.scope.names[n.Name.Value]}
+		}
+	case *ast.Identifier:
+		n.Ref = r.lookup(n)
+	case *ast.FunctionLiteral:
+		r.function(n)
+	case *ast.MacroLiteral:
+		// macros are gone once they are expanded; the ones left can't be
+		// called
+	case *ast.Call:
+		if isQuote(n) {
+			// quoted code runs only if it is unquoted into a macro's
+			// expansion, which is resolved as part of the program
+			return
+		}
+		r.node(n.Function)
+		for _, a := range n.Arguments {
+			r.node(a)
+		}
+	case nil:
+	default:
+		r.children(node)
+	}
+}
+
+// children resolves the nodes right below node.
+func (r *resolver) children(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil || n == node {
+			return n != nil
+		}
+		if _, ok := n.(ast.Type); ok {
+			return false
+		}
+		r.node(n)
+		return false
+	})
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	s := &scope{outer: r.scope, names: map[string]int{}}
+	for _, p := range fn.Params {
+		if p != nil {
+			p.Ref = &ast.Ref{Depth: 0, Slot: s.bind(p.Value)}
+		}
+	}
+	locals(fn, s)
+	r.scope = s
+	r.node(fn.Body)
+	r.scope = s.outer
+	fn.Locals = s.locals
+}
+
+// lookup returns where the binding ident refers to lives, or nil for a
+// global, and reports ident if there is no such binding.
+func (r *resolver) lookup(ident *ast.Identifier) *ast.Ref {
+	depth := 0
+	for s := r.scope; s.outer != nil; s = s.outer {
+		if slot, ok := s.names[ident.Value]; ok {
+			return &ast.Ref{Depth: depth, Slot: slot}
+		}
+		depth++
+	}
+	if !r.global(ident.Value) {
+		r.errors = append(r.errors, &Error{
+			Line:    ident.Token.Line,
+			Column:  ident.Token.Column,
+			Message: "identifier not found " + ident.Value,
+		})
+	}
+	return nil
+}
+
+func (r *resolver) global(name string) bool {
+	if _, ok := r.top().names[name]; ok {
+		return true
+	}
+	if r.env != nil {
+		if _, ok := r.env.Get(name); ok {
+			return true
+		}
+	}
+	for _, b := range evaluator.Builtins() {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolver) top() *scope {
+	s := r.scope
+	for s.outer != nil {
+		s = s.outer
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package resolver
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+)
+
+func parse(t testing.TB, src string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: unexpected syntax errors %v", src, p.Errors())
+	}
+	return program
+}
+
+// refs lists the identifiers in program with where they were resolved to,
+// name@depth.slot, or just the name for a global.
+func refs(program *ast.Program) string {
+	var out []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			if ident.Ref == nil {
+				out = append(out, ident.Value)
+			} else {
+				out = append(out, fmt.Sprintf("%s@%d.%d", ident.Value, ident.Ref.Depth, ident.Ref.Slot))
+			}
+		}
+		return true
+	})
+	return strings.Join(out, " ")
+}
+
+func TestResolve(t *testing.T) {
+	input := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x", "x x"},
+		{"let f = fn(a, b) { a + b }; f(1, 2)", "f a@0.0 b@0.1 a@0.0 b@0.1 f"},
+		{"let f = fn(a) { let b = a; b }", "f a@0.0 b@0.1 a@0.0 b@0.1"},
+		{"let f = fn(a) { fn(b) { a + b } }", "f a@0.0 b@0.0 a@1.0 b@0.0"},
+		{"let f = fn(a) { let a = a + 1; a }", "f a@0.0 a@0.0 a@0.0 a@0.0"},
+		{"let f = fn() { if (true) { let x = 1; } x }", "f x@0.0 x@0.0"},
+		{"let f = fn() { let g = fn() { h() }; let h = fn() { 1 }; g() }", "f g@0.0 h@1.1 h@0.1 g@0.0"},
+		{"let f = fn(n) { if (n < 2) { return n; } f(n - 1) }", "f n@0.0 n@0.0 n@0.0 f n@0.0"},
+		{"let f = fn(x) { puts(x) }", "f x@0.0 puts x@0.0"},
+		{"let f = fn(x) { quote(x + y) }", "f x@0.0 quote x y"},
+	}
+	for _, tt := range input {
+		program := parse(t, tt.input)
+		if errs := Resolve(program, nil); len(errs) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, errs)
+		}
+		if got := refs(program); got != tt.expected {
+			t.Errorf("%q: expected %q got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestUnbound(t *testing.T) {
+	input := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; y + x", []string{"1:12: identifier not found y"}},
+		{"let f = fn(a) { a + b }; f(c)", []string{"1:21: identifier not found b", "1:28: identifier not found c"}},
+		{"let f = fn() { let a = 1; }; a", []string{"1:30: identifier not found a"}},
+		{"let f = fn() { g() }; let g = fn() { 1 };", nil},
+		{"puts(1); assert(true)", nil},
+	}
+	for _, tt := range input {
+		var got []string
+		for _, e := range Resolve(parse(t, tt.input), nil) {
+			got = append(got, e.Error())
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: expected %q got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestEnviroment(t *testing.T) {
+	env := object.NewEnviroment()
+	evaluator.Eval(parse(t, "let x = 1;"), env)
+	program := parse(t, "x + y")
+	errs := Resolve(program, env)
+	if len(errs) != 1 || errs[0].Message != "identifier not found y" {
+		t.Errorf("expected only y to be unbound, got %v", errs)
+	}
+}
+
+func TestEval(t *testing.T) {
+	input := []struct {
+		input    string
+		expected int
+	}{
+		{"let add = fn(a, b) { a + b }; add(2, 3)", 5},
+		{"let adder = fn(a) { fn(b) { a + b } }; adder(2)(3)", 5},
+		{"let f = fn(a) { let a = a * 2; let g = fn() { a }; let a = a + 1; g() }; f(5)", 11},
+		{"let f = fn() { let g = fn() { h() }; let h = fn() { 7 }; g() }; f()", 7},
+		{"let x = 10; let f = fn() { let y = x; let x = 1; x + y }; f()", 11},
+		{"let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15)", 610},
+		{"let f = fn(n) { if (n > 0) { let m = n; } else { let m = 0 - n; } m }; f(-4)", 4},
+	}
+	for _, tt := range input {
+		program := parse(t, tt.input)
+		if errs := Resolve(program, nil); len(errs) != 0 {
+			t.Fatalf("%q: unexpected errors %v", tt.input, errs)
+		}
+		got, ok := evaluator.Eval(program, object.NewEnviroment()).(*object.Integer)
+		if !ok || got.Value != tt.expected {
+			t.Errorf("%q: expected %d got %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+const fib = "let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(20)"
+
+// BenchmarkFib compares a recursive fibonacci run with its names looked up
+// by name with the same run after resolving them to slots.
+func BenchmarkFib(b *testing.B) {
+	for _, resolved := range []bool{false, true} {
+		name := "maps"
+		if resolved {
+			name = "slots"
+		}
+		b.Run(name, func(b *testing.B) {
+			program := parse(b, fib)
+			if resolved {
+				Resolve(program, nil)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				evaluator.Eval(program, object.NewEnviroment())
+			}
+		})
+	}
+}
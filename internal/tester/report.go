@@ -0,0 +1,148 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText reports f the way `go test` reports a package: the failed
+// tests with their output, or every test with -v, and then a line saying
+// whether the file passed.
+func WriteText(w io.Writer, f *File, verbose bool) {
+	if f.Err != nil {
+		fmt.Fprint(w, f.Output)
+		fmt.Fprintln(w, f.Err)
+		fmt.Fprintf(w, "FAIL\t%s [setup failed]\n", f.Path)
+		return
+	}
+	if verbose {
+		fmt.Fprint(w, f.Output)
+	}
+	for _, t := range f.Tests {
+		if verbose {
+			fmt.Fprintf(w, "=== RUN   %s\n", t.Name)
+		}
+		switch {
+		case t.Passed() && verbose:
+			fmt.Fprint(w, t.Output)
+			fmt.Fprintf(w, "--- PASS: %s (%s)\n", t.Name, seconds(t.Elapsed))
+		case verbose:
+			// the output comes as the test runs, so before the result
+			fmt.Fprint(w, t.Output)
+			fmt.Fprintf(w, "--- FAIL: %s (%s)\n", t.Name, seconds(t.Elapsed))
+			fmt.Fprintln(w, indent(t.Failure))
+		case !t.Passed():
+			fmt.Fprintf(w, "--- FAIL: %s (%s)\n", t.Name, seconds(t.Elapsed))
+			if t.Output != "" {
+				fmt.Fprintln(w, indent(strings.TrimSuffix(t.Output, "\n")))
+			}
+			fmt.Fprintln(w, indent(t.Failure))
+		}
+	}
+	switch {
+	case f.Failed():
+		fmt.Fprintf(w, "FAIL\t%s\t%s\n", f.Path, seconds(f.Elapsed))
+	case len(f.Tests) == 0:
+		fmt.Fprintf(w, "ok  \t%s\t%s [no tests to run]\n", f.Path, seconds(f.Elapsed))
+	default:
+		fmt.Fprintf(w, "ok  \t%s\t%s\n", f.Path, seconds(f.Elapsed))
+	}
+}
+
+// indent indents every line of s by four spaces.
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Time      string      `xml:"time,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit reports files as JUnit XML, which CI servers read, with a
+// test suite for each file. A file that failed before its tests ran has
+// an error instead of test cases.
+func WriteJUnit(w io.Writer, files []*File) error {
+	var suites junitSuites
+	var total time.Duration
+	for _, f := range files {
+		suite := junitSuite{
+			Name:      f.Path,
+			Time:      junitTime(f.Elapsed),
+			SystemOut: f.Output,
+		}
+		if f.Err != nil {
+			suite.Tests, suite.Errors = 1, 1
+			suite.Cases = append(suite.Cases, junitCase{
+				ClassName: f.Path,
+				Name:      "setup",
+				Time:      junitTime(0),
+				Error:     &junitFailure{Message: f.Err.Error(), Text: f.Err.Error()},
+			})
+		}
+		for _, t := range f.Tests {
+			c := junitCase{ClassName: f.Path, Name: t.Name, Time: junitTime(t.Elapsed), SystemOut: t.Output}
+			if !t.Passed() {
+				c.Failure = &junitFailure{Message: t.Failure, Text: t.Failure}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, c)
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		total += f.Elapsed
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = junitTime(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
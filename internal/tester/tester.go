@@ -0,0 +1,247 @@
+// Package tester finds and runs Monkey tests. A test file is named
+// *_test.monkey and its tests are the functions it binds at the top level
+// to names starting with test_. They take no arguments and fail when they
+// end with a runtime error, like the ones the assert builtins raise.
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/lexer"
+	"github.com/myselfBZ/interpreter/internal/object"
+	"github.com/myselfBZ/interpreter/internal/parser"
+	"github.com/myselfBZ/interpreter/internal/resolver"
+)
+
+// Suffix ends the names of test files.
+const Suffix = "_test.monkey"
+
+// Prefix starts the names of test functions.
+const Prefix = "test_"
+
+// A Test is the result of one test function.
+type Test struct {
+	Name string
+	// Failure is the error the test ended with, empty if it passed
+	Failure string
+	// Output is what the test printed with puts
+	Output  string
+	Elapsed time.Duration
+}
+
+func (t *Test) Passed() bool {
+	return t.Failure == ""
+}
+
+// A File is the result of the tests of one file.
+type File struct {
+	Path string
+	// Err is set if the file couldn't be read or parsed, or its top level
+	// failed; none of its tests ran then
+	Err     error
+	Tests   []*Test
+	Elapsed time.Duration
+	// Output is what the top level of the file printed
+	Output string
+}
+
+func (f *File) Failed() bool {
+	if f.Err != nil {
+		return true
+	}
+	for _, t := range f.Tests {
+		if !t.Passed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the test files patterns name, sorted. A pattern is a test
+// file, a directory, whose test files are taken, or a directory followed
+// by /... to take the test files below it too, like ./... for all of
+// them.
+func Find(patterns []string) ([]string, error) {
+	found := map[string]bool{}
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "..."); ok {
+			dir = filepath.Clean(strings.TrimSuffix(dir, "/"))
+			if dir == "" {
+				dir = "."
+			}
+			err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if !d.IsDir() && strings.HasSuffix(path, Suffix) {
+					found[path] = true
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found[pattern] = true
+			continue
+		}
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), Suffix) {
+				found[filepath.Join(pattern, e.Name())] = true
+			}
+		}
+	}
+	files := make([]string, 0, len(found))
+	for path := range found {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run runs the tests of the file at path whose names match, or all of
+// them if match is nil. The file runs in an enviroment of its own, and
+// its top level runs before the tests.
+func Run(path string, match *regexp.Regexp) *File {
+	start := time.Now()
+	f := run(path, match)
+	f.Elapsed = time.Since(start)
+	return f
+}
+
+func run(path string, match *regexp.Regexp) *File {
+	f := &File{Path: path}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+	l := lexer.New(string(src))
+	l.EmitComments(true)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		f.Err = located(path, strings.TrimSpace(p.Errors()[0]))
+		return f
+	}
+	macroEnv := object.NewEnviroment()
+	evaluator.DefineMacros(program, macroEnv)
+	program, err = evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		f.Err = located(path, err.Error())
+		return f
+	}
+	if errs := resolver.Resolve(program, nil); len(errs) != 0 {
+		f.Err = located(path, errs[0].Error())
+		return f
+	}
+
+	var out bytes.Buffer
+	defer evaluator.SetOutput(evaluator.SetOutput(&out))
+	env := object.NewEnviroment()
+	result := protect(func() object.Object { return evaluator.Eval(program, env) })
+	f.Output = out.String()
+	if e, ok := result.(*object.Error); ok {
+		f.Err = located(path, e.Inspect())
+		return f
+	}
+
+	for _, let := range tests(program) {
+		name := let.Name.Value
+		if match != nil && !match.MatchString(name) {
+			continue
+		}
+		out.Reset()
+		start := time.Now()
+		t := &Test{Name: name}
+		fn, _ := env.Get(name)
+		if failure := call(let, fn); failure != nil {
+			t.Failure = located(path, failure.Inspect()).Error()
+		}
+		t.Elapsed = time.Since(start)
+		t.Output = out.String()
+		f.Tests = append(f.Tests, t)
+	}
+	return f
+}
+
+// tests returns the lets that bind test functions at the top level of
+// program, the first for each name.
+func tests(program *ast.Program) []*ast.LetStatement {
+	var lets []*ast.LetStatement
+	seen := map[string]bool{}
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let.Name == nil || !strings.HasPrefix(let.Name.Value, Prefix) || seen[let.Name.Value] {
+			continue
+		}
+		if _, ok := let.Value.(*ast.FunctionLiteral); ok {
+			seen[let.Name.Value] = true
+			lets = append(lets, let)
+		}
+	}
+	return lets
+}
+
+// call calls the test function let binds, fn, and returns the error it
+// ends with, if any.
+func call(let *ast.LetStatement, fn object.Object) *object.Error {
+	if f, ok := fn.(*object.Function); ok && len(f.Params) != 0 {
+		return &object.Error{
+			Message: fmt.Sprintf("test functions take no arguments, %s takes %d", let.Name.Value, len(f.Params)),
+			Line:    let.Name.Token.Line,
+			Column:  let.Name.Token.Column,
+		}
+	}
+	if e, ok := protect(func() object.Object { return evaluator.Apply(fn) }).(*object.Error); ok {
+		if e.Line == 0 {
+			e.Line, e.Column = let.Name.Token.Line, let.Name.Token.Column
+		}
+		return e
+	}
+	return nil
+}
+
+// protect returns what eval returns, or an error if it panics, so a test
+// that crashes the interpreter fails alone rather than ending the run.
+func protect(eval func() object.Object) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = &object.Error{Message: fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+	return eval()
+}
+
+// located prefixes msg, which may start with a line and column, with
+// path, like path:line:column: msg.
+func located(path, msg string) error {
+	var line, column int
+	if n, _ := fmt.Sscanf(msg, "%d:%d:", &line, &column); n == 2 {
+		return fmt.Errorf("%s:%s", path, msg)
+	}
+	return fmt.Errorf("%s: %s", path, msg)
+}
@@ -0,0 +1,222 @@
+package tester
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/myselfBZ/interpreter/internal/ast"
+	"github.com/myselfBZ/interpreter/internal/evaluator"
+	"github.com/myselfBZ/interpreter/internal/object"
+)
+
+const mathTest = `let add = fn(a, b) { a + b };
+puts(0);
+
+let test_add = fn() {
+    assert_eq(add(1, 2), 3);
+    assert(add(2, 2) == 4);
+};
+
+let test_broken = fn() {
+    puts(add(1, 1));
+    assert_eq(add(1, 2), 4);
+};
+
+let test_error = fn() {
+    assert_error(fn() { add(1, true) });
+};
+
+let test_args = fn(x) { x };
+let helper = fn() { assert(false) };
+`
+
+// tree writes files, by their path relative to a new directory, and
+// changes to that directory for the test.
+func tree(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for path, src := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestFind(t *testing.T) {
+	tree(t, map[string]string{
+		"a_test.monkey":            "",
+		"a.monkey":                 "",
+		"sub/b_test.monkey":        "",
+		"sub/deeper/c_test.monkey": "",
+		".hidden/d_test.monkey":    "",
+	})
+	input := []struct {
+		patterns []string
+		expected []string
+	}{
+		{[]string{"."}, []string{"a_test.monkey"}},
+		{[]string{"./..."}, []string{"a_test.monkey", "sub/b_test.monkey", "sub/deeper/c_test.monkey"}},
+		{[]string{"sub/..."}, []string{"sub/b_test.monkey", "sub/deeper/c_test.monkey"}},
+		{[]string{"sub", "sub/deeper/c_test.monkey", "a.monkey"}, []string{"a.monkey", "sub/b_test.monkey", "sub/deeper/c_test.monkey"}},
+	}
+	for _, tt := range input {
+		got, err := Find(tt.patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%v: expected %v, got %v", tt.patterns, tt.expected, got)
+		}
+	}
+	if _, err := Find([]string{"missing"}); err == nil {
+		t.Errorf("expected a missing path to be an error")
+	}
+}
+
+func TestRun(t *testing.T) {
+	tree(t, map[string]string{
+		"math_test.monkey": mathTest,
+		"bad_test.monkey":  "let x = ;",
+		"fail_test.monkey": "let test_never = fn() { 1 };\n1 + true",
+	})
+	f := Run("math_test.monkey", nil)
+	if f.Err != nil || f.Output != "0\n" || !f.Failed() {
+		t.Fatalf("unexpected result %+v", f)
+	}
+	var got []string
+	for _, test := range f.Tests {
+		got = append(got, test.Name+"|"+test.Output+"|"+test.Failure)
+	}
+	expected := []string{
+		"test_add||",
+		"test_broken|2\n|math_test.monkey:11:14: assert_eq failed: expected 4, got 3",
+		"test_error||",
+		"test_args||math_test.monkey:18:5: test functions take no arguments, test_args takes 1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	f = Run("math_test.monkey", regexp.MustCompile("add|error"))
+	if len(f.Tests) != 2 || f.Failed() {
+		t.Fatalf("expected two tests to pass, got %+v", f.Tests)
+	}
+	if f := Run("bad_test.monkey", nil); f.Err == nil || f.Err.Error() != "bad_test.monkey:1:9: no prefix func for ;" {
+		t.Errorf("expected the syntax error, got %v", f.Err)
+	}
+	if f := Run("fail_test.monkey", nil); f.Err == nil || !strings.HasPrefix(f.Err.Error(), "fail_test.monkey:2:") || len(f.Tests) != 0 {
+		t.Errorf("expected the top level to fail, got %v", f.Err)
+	}
+}
+
+// boomHook panics when a function named boom is called, like a bug in
+// the interpreter would.
+type boomHook struct{}
+
+func (boomHook) Statement(ast.Statement, *object.Enviroment) {}
+func (boomHook) Return(*ast.Call, object.Object)             {}
+func (boomHook) Call(call *ast.Call, fn object.Object) {
+	if call.Function.String() == "boom" {
+		panic("boom")
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	tree(t, map[string]string{
+		"panic_test.monkey": `let boom = fn() { 1 };
+let test_boom = fn() { boom() };
+let test_after = fn() { assert(true) };
+`,
+		"top_test.monkey": "let boom = fn() { 1 };\nboom();",
+	})
+	defer evaluator.SetHook(evaluator.SetHook(boomHook{}))
+	f := Run("panic_test.monkey", nil)
+	var got []string
+	for _, test := range f.Tests {
+		got = append(got, test.Name+"|"+test.Failure)
+	}
+	expected := []string{"test_boom|panic_test.monkey:2:5: panic: boom", "test_after|"}
+	if f.Err != nil || !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %q, got %q (%v)", expected, got, f.Err)
+	}
+	if f := Run("top_test.monkey", nil); f.Err == nil || f.Err.Error() != "top_test.monkey: panic: boom" {
+		t.Errorf("expected the top level to fail, got %v", f.Err)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	tree(t, map[string]string{"math_test.monkey": mathTest})
+	f := Run("math_test.monkey", regexp.MustCompile("add|broken"))
+	f.Elapsed = 0
+	for _, test := range f.Tests {
+		test.Elapsed = 0
+	}
+	var out strings.Builder
+	WriteText(&out, f, false)
+	expected := `--- FAIL: test_broken (0.000s)
+    2
+    math_test.monkey:11:14: assert_eq failed: expected 4, got 3
+FAIL	math_test.monkey	0.000s
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	WriteText(&out, f, true)
+	expected = `0
+=== RUN   test_add
+--- PASS: test_add (0.000s)
+=== RUN   test_broken
+2
+--- FAIL: test_broken (0.000s)
+    math_test.monkey:11:14: assert_eq failed: expected 4, got 3
+FAIL	math_test.monkey	0.000s
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	tree(t, map[string]string{
+		"math_test.monkey": mathTest,
+		"bad_test.monkey":  "let x = ;",
+	})
+	var out strings.Builder
+	if err := WriteJUnit(&out, []*File{Run("bad_test.monkey", nil), Run("math_test.monkey", nil)}); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal([]byte(out.String()), &suites); err != nil {
+		t.Fatalf("the report isn't XML: %s\n%s", err, out.String())
+	}
+	if suites.Tests != 5 || suites.Failures != 2 || suites.Errors != 1 || len(suites.Suites) != 2 {
+		t.Fatalf("unexpected totals in\n%s", out.String())
+	}
+	bad, math := suites.Suites[0], suites.Suites[1]
+	if bad.Cases[0].Error == nil || bad.Cases[0].Error.Message != "bad_test.monkey:1:9: no prefix func for ;" {
+		t.Errorf("expected the syntax error in %+v", bad)
+	}
+	broken := math.Cases[1]
+	if broken.Name != "test_broken" || broken.Failure == nil || broken.SystemOut != "2\n" || math.SystemOut != "0\n" {
+		t.Errorf("unexpected test case %+v", broken)
+	}
+}
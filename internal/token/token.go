@@ -5,6 +5,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType `json:"type"`
 	Literal string    `json:"literal"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
 }
 
 func NewToken(kind TokenType, literal string) Token {
@@ -19,11 +21,13 @@ var Keywords = map[string]string{
 	"false":  FALSE,
 	"true":   TRUE,
 	"let":    LET,
+	"macro":  MACRO,
 }
 
 const (
 	ILLEGAL        = "ILLEGAL"
 	EOF            = "EOF"
+	COMMENT        = "COMMENT"
 	IDENT          = "IDENT"
 	INT            = "INT"
 	ASSIGN         = "="
@@ -34,8 +38,11 @@ const (
 	RPAREN         = ")"
 	LBRACE         = "{"
 	RBRACE         = "}"
+	LBRACKET       = "["
+	RBRACKET       = "]"
 	FUNCTION       = "FUNCTION"
 	LET            = "LET"
+	MACRO          = "MACRO"
 	MINUS          = "-"
 	DIVISION       = "/"
 	MULTIPLICATION = "*"
@@ -51,4 +58,8 @@ const (
 	GTOREQ         = ">="
 	LTOREQ         = "<="
 	BANG           = "!"
+	// in type annotations
+	COLON = ":"
+	PIPE  = "|"
+	ARROW = "->"
 )